@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"os"
 	"path"
 	"strings"
 
@@ -15,6 +17,19 @@ import (
 )
 
 var separator string
+var diffKeys []string
+var diffFormat string
+var diffIgnore []string
+var diffOnly []string
+var diffIgnoreFile string
+
+// output formats of the diff command
+const (
+	DIFF_TEXT      = "text"
+	DIFF_PLAIN     = "plain"
+	DIFF_JSON      = "json"
+	DIFF_JSONPATCH = "jsonpatch"
+)
 
 // diffCmd represents the diff command
 var diffCmd = &cobra.Command{
@@ -26,15 +41,25 @@ Here streams with multiple documents are supported, also. To indicate
 no difference the number of documents in both streams must be identical
 and each document in the first stream must have no difference compared
 to the document with the same index in the second stream. Found differences
-are shown for each document separately.`,
+are shown for each document separately. If differences are found, the
+command exits with status 1.`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(args) != 2 {
 			return errors.New("requires two args")
 		}
+		switch diffFormat {
+		case DIFF_TEXT, DIFF_PLAIN, DIFF_JSON, DIFF_JSONPATCH:
+		default:
+			return fmt.Errorf("invalid output format %q", diffFormat)
+		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		diff(args[0], args[1], separator)
+		opts, err := diffOptions()
+		if err != nil {
+			trouble(err)
+		}
+		diff(args[0], args[1], separator, opts)
 	},
 }
 
@@ -42,56 +67,140 @@ func init() {
 	rootCmd.AddCommand(diffCmd)
 
 	diffCmd.Flags().StringVar(&separator, "separator", "", "Separator to print between diffs")
+	diffCmd.Flags().StringVar(&diffFormat, "format", DIFF_TEXT, "output format (text, plain, json or jsonpatch)")
+	diffCmd.Flags().StringArrayVar(&diffKeys, "key", []string{}, "key field used to match list entries ([<path>=]<field>)")
+	diffCmd.Flags().StringArrayVar(&diffIgnore, "ignore", []string{}, "path pattern of nodes to ignore")
+	diffCmd.Flags().StringArrayVar(&diffOnly, "only", []string{}, "path pattern of nodes to compare")
+	diffCmd.Flags().StringVar(&diffIgnoreFile, "ignore-file", "", "file with path patterns of nodes to ignore")
+}
+
+func diffOptions() (*compare.Options, error) {
+	opts := &compare.Options{}
+	for _, k := range diffKeys {
+		opts.AddKey(k)
+	}
+	if diffIgnoreFile != "" {
+		data, err := ReadFile(diffIgnoreFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading ignore file [%s]: %s", path.Clean(diffIgnoreFile), err)
+		}
+		opts.Ignore = append(opts.Ignore, compare.ParsePatterns(data)...)
+	}
+	opts.Ignore = append(opts.Ignore, diffIgnore...)
+	opts.Only = append(opts.Only, diffOnly...)
+	return opts, nil
 }
 
-func diff(aFilePath, bFilePath string, separator string) {
+func diff(aFilePath, bFilePath string, separator string, opts *compare.Options) {
 	aFile, err := ReadFile(aFilePath)
 	if err != nil {
-		log.Fatalln(fmt.Sprintf("error reading a [%s]:", path.Clean(aFilePath)), err)
+		trouble(fmt.Sprintf("error reading a [%s]:", path.Clean(aFilePath)), err)
 	}
 
 	aYAMLs, err := yaml.ParseMulti(aFilePath, aFile)
 	if err != nil {
-		log.Fatalln(fmt.Sprintf("error parsing a [%s]:", path.Clean(aFilePath)), err)
+		trouble(fmt.Sprintf("error parsing a [%s]:", path.Clean(aFilePath)), err)
 	}
 
 	bFile, err := ReadFile(bFilePath)
 	if err != nil {
-		log.Fatalln(fmt.Sprintf("error reading b [%s]:", path.Clean(bFilePath)), err)
+		trouble(fmt.Sprintf("error reading b [%s]:", path.Clean(bFilePath)), err)
 	}
 
 	bYAMLs, err := yaml.ParseMulti(bFilePath, bFile)
 	if err != nil {
-		log.Fatalln(fmt.Sprintf("error parsing b [%s]:", path.Clean(bFilePath)), err)
+		trouble(fmt.Sprintf("error parsing b [%s]:", path.Clean(bFilePath)), err)
 	}
 
 	if len(aYAMLs) != len(bYAMLs) {
-		fmt.Printf("Different number of documents (%d != %d)\n", len(aYAMLs), len(bYAMLs))
-		return
+		if diffFormat == DIFF_TEXT || diffFormat == DIFF_PLAIN {
+			fmt.Printf("Different number of documents (%d != %d)\n", len(aYAMLs), len(bYAMLs))
+		} else {
+			log.Printf("different number of documents (%d != %d)\n", len(aYAMLs), len(bYAMLs))
+		}
+		exit(EXIT_DIFFERENT)
 	}
 
 	ddiffs := make([][]compare.Diff, len(aYAMLs))
 	found := false
 	for no, aYAML := range aYAMLs {
 		bYAML := bYAMLs[no]
-		ddiffs[no] = compare.Compare(aYAML, bYAML)
+		ddiffs[no] = compare.CompareWithOptions(aYAML, bYAML, opts)
 		if len(ddiffs[no]) != 0 {
 			found = true
 		}
 	}
+
+	switch diffFormat {
+	case DIFF_JSON:
+		records := []compare.Record{}
+		for no, diffs := range ddiffs {
+			doc := 0
+			if len(ddiffs) > 1 {
+				doc = no + 1
+			}
+			r, err := compare.Records(doc, diffs)
+			if err != nil {
+				trouble("error converting differences:", err)
+			}
+			records = append(records, r...)
+		}
+		printJSON(records)
+	case DIFF_JSONPATCH:
+		patches := [][]compare.PatchOperation{}
+		for _, diffs := range ddiffs {
+			p, err := compare.JSONPatch(diffs)
+			if err != nil {
+				trouble("error converting differences:", err)
+			}
+			patches = append(patches, p)
+		}
+		if len(patches) == 1 {
+			printJSON(patches[0])
+		} else {
+			printJSON(patches)
+		}
+	default:
+		printDiffs(aFilePath, bFilePath, separator, ddiffs)
+	}
+	if found {
+		exit(EXIT_DIFFERENT)
+	}
+}
+
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		trouble("error marshalling JSON:", err)
+	}
+}
+
+func printDiffs(aFilePath, bFilePath string, separator string, ddiffs [][]compare.Diff) {
+	found := false
+	for _, diffs := range ddiffs {
+		if len(diffs) != 0 {
+			found = true
+		}
+	}
 	if !found {
 		fmt.Println("no differences!")
 		return
 	}
-	for no := range aYAMLs {
-		if len(ddiffs[no]) == 0 {
-			if len(aYAMLs) > 1 {
+
+	red, green, reset := "\x1b[31m", "\x1b[32m", "\x1b[0m"
+	if diffFormat == DIFF_PLAIN {
+		red, green, reset = "", "", ""
+	}
+	for no, diffs := range ddiffs {
+		if len(diffs) == 0 {
+			if len(ddiffs) > 1 {
 				fmt.Printf("No difference in document %d\n", no+1)
 			}
 		} else {
-			diffs := ddiffs[no]
 			doc := ""
-			if len(aYAMLs) > 1 {
+			if len(ddiffs) > 1 {
 				doc = fmt.Sprintf("document %d", no+1)
 			}
 			for _, diff := range diffs {
@@ -103,7 +212,7 @@ func diff(aFilePath, bFilePath string, separator string) {
 						panic(err)
 					}
 
-					fmt.Printf("  %s has:\n    \x1b[31m%s\x1b[0m\n", aFilePath, strings.Replace(string(ayaml), "\n", "\n    ", -1))
+					fmt.Printf("  %s has:\n    %s%s%s\n", aFilePath, red, strings.Replace(string(ayaml), "\n", "\n    ", -1), reset)
 				}
 
 				if diff.B != nil {
@@ -112,7 +221,7 @@ func diff(aFilePath, bFilePath string, separator string) {
 						panic(err)
 					}
 
-					fmt.Printf("  %s has:\n    \x1b[32m%s\x1b[0m\n", bFilePath, strings.Replace(string(byaml), "\n", "\n    ", -1))
+					fmt.Printf("  %s has:\n    %s%s%s\n", bFilePath, green, strings.Replace(string(byaml), "\n", "\n    ", -1), reset)
 				}
 
 				fmt.Printf(separator)
@@ -37,6 +37,8 @@ func init() {
 	rootCmd.AddCommand(encryptCmd)
 
 	encryptCmd.Flags().BoolVarP(&decrypt, "decrypt", "d", false, "decrypt content")
+
+	addKeyFileFlag(encryptCmd)
 }
 
 func encrypt(decrypt bool, args []string) {
@@ -55,8 +57,13 @@ func encrypt(decrypt bool, args []string) {
 		log.Fatalln(fmt.Sprintf("error reading data [%s]:", path.Clean(filePath)), err)
 	}
 
-	key := os.Getenv("SPIFF_ENCRYPTION_KEY")
+	key := encryptionKey()
 	method := passwd.TRIPPLEDES
+	if decrypt {
+		if detected := passwd.DetectEncoding(string(file)); detected != "" {
+			method = detected
+		}
+	}
 	v := ""
 	if len(args) > 1 {
 		v = args[1]
@@ -70,6 +77,10 @@ func encrypt(decrypt bool, args []string) {
 			key = v
 		}
 	case 3:
+		// an empty password keeps the configured key
+		if v != "" {
+			key = v
+		}
 		method = args[2]
 	}
 
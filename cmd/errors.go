@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+// exit codes used for failing merges
+const (
+	EXIT_ERROR      = 1 // general errors, like unreadable or unparseable files
+	EXIT_UNRESOLVED = 2 // nodes could not be resolved (cycles or pending references)
+	EXIT_FAILED     = 3 // evaluation of at least one expression failed
+)
+
+// exit codes used by the diff and merge3 commands, following diff(1)
+const (
+	EXIT_DIFFERENT = 1 // differences or conflicts found
+	EXIT_TROUBLE   = 2 // errors, like unreadable or unparseable files
+)
+
+var errorsJSON bool
+
+// fatal and exit terminate the processing with an error. They are
+// replaced in watch mode to continue watching after failed merges.
+var fatal = log.Fatalln
+var exit = os.Exit
+
+// trouble reports an error of a command comparing documents and
+// terminates the processing with EXIT_TROUBLE, which is distinguishable
+// from EXIT_DIFFERENT.
+func trouble(v ...interface{}) {
+	log.Println(v...)
+	exit(EXIT_TROUBLE)
+}
+
+const legend = "\nerror classification:\n" +
+	" *: error in local dynaml expression\n" +
+	" @: dependent of or involved in a cycle\n" +
+	" -: depending on a node with an error"
+
+type issueReport struct {
+	Issue  string        `json:"issue"`
+	Nested []issueReport `json:"nested,omitempty"`
+}
+
+type nodeReport struct {
+	Path           string        `json:"path"`
+	Context        []string      `json:"context"`
+	ReferredPath   string        `json:"referredPath,omitempty"`
+	Source         string        `json:"source"`
+	Line           int           `json:"line,omitempty"`
+	Column         int           `json:"column,omitempty"`
+	Expression     string        `json:"expression"`
+	Classification string        `json:"classification"`
+	Issue          string        `json:"issue,omitempty"`
+	Nested         []issueReport `json:"nested,omitempty"`
+}
+
+func exitCode(unresolved dynaml.UnresolvedNodes) int {
+	for _, n := range unresolved.Nodes {
+		if dynaml.Classification(n) != dynaml.CLASS_CYCLE {
+			return EXIT_FAILED
+		}
+	}
+	return EXIT_UNRESOLVED
+}
+
+func issueReports(issues []yaml.Issue) []issueReport {
+	var result []issueReport
+	for _, i := range issues {
+		result = append(result, issueReport{i.Issue, issueReports(i.Nested)})
+	}
+	return result
+}
+
+func expressionText(node yaml.Node) string {
+	switch v := node.Value().(type) {
+	case dynaml.Expression:
+		return fmt.Sprintf("(( %s ))", v)
+	case map[string]yaml.Node:
+		return "<map>"
+	case []yaml.Node:
+		return "<list>"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// unresolvedReport describes every unresolved node once, ordered by
+// path, because the flow may report nodes several times and in
+// varying order.
+func unresolvedReport(unresolved dynaml.UnresolvedNodes) []nodeReport {
+	result := []nodeReport{}
+	found := map[string]bool{}
+	for _, n := range unresolved.Nodes {
+		path := strings.Join(n.Context, ".")
+		if found[path] {
+			continue
+		}
+		found[path] = true
+		issue := n.Issue()
+		result = append(result, nodeReport{
+			Path:           path,
+			Context:        n.Context,
+			ReferredPath:   strings.Join(n.Path, "."),
+			Source:         n.SourceName(),
+			Line:           n.SourceLine(),
+			Column:         n.SourceColumn(),
+			Expression:     expressionText(n),
+			Classification: dynaml.Classification(n),
+			Issue:          issue.Issue,
+			Nested:         issueReports(issue.Nested),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result
+}
+
+// failOnEvaluationError reports an error of a merge and terminates the
+// process. Unresolved nodes are reported either as text or, if requested,
+// as json document on stderr, and terminate with a dedicated exit code.
+func failOnEvaluationError(msg string, err error) {
+	unresolved, ok := err.(dynaml.UnresolvedNodes)
+	if !ok {
+		fatal(msg, err)
+	}
+	if errorsJSON {
+		encoder := json.NewEncoder(os.Stderr)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if jerr := encoder.Encode(unresolvedReport(unresolved)); jerr != nil {
+			fatal(msg, err)
+		}
+	} else {
+		log.Println(msg, err, legend)
+	}
+	exit(exitCode(unresolved))
+}
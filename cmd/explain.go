@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mandelsoft/spiff/debug"
+	"github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/flow"
+	"github.com/spf13/cobra"
+)
+
+// explainCmd represents the explain command
+var explainCmd = &cobra.Command{
+	Use:   "explain <path> <template> [<stub> ...]",
+	Short: "Explain the evaluation of a node of a merge",
+	Long:  `Merge a bunch of template files and explain how the node with the given path got its value.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("requires at least two args")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		explain(args[0], args[1], partial, args[2:])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+
+	explainCmd.Flags().BoolVar(&debug.DebugFlag, "debug", false, "Print state info")
+
+	explainCmd.Flags().BoolVar(&partial, "partial", false, "Allow partial evaluation only")
+
+	addPolicyFlags(explainCmd)
+
+	addKeyFileFlag(explainCmd)
+}
+
+func explain(nodePath string, templateFilePath string, partial bool, stubFilePaths []string) {
+	templateYAMLs, stubs := readTemplateAndStubs(templateFilePath, stubFilePaths)
+
+	outer := outerEnvironment()
+	defer flow.CleanupEnvironment(outer)
+
+	prepared, err := flow.PrepareStubs(outer, partial, stubs...)
+	if !partial && err != nil {
+		failOnEvaluationError("error generating manifest:", err)
+	}
+
+	comps := dynaml.PathComponents(nodePath, false)
+	for no, templateYAML := range templateYAMLs {
+		if templateYAML.Value() == nil {
+			continue
+		}
+		doc := ""
+		if len(templateYAMLs) > 1 {
+			doc = fmt.Sprintf(" (document %d)", no+1)
+			fmt.Printf("--- document %d\n", no+1)
+		}
+		_, explanation, err := flow.Explain(outer, templateYAML, prepared, comps)
+		if !explanation.Found && err == nil {
+			fatal(fmt.Sprintf("path %q not found%s", nodePath, doc))
+		}
+		fmt.Print(explanation)
+		if err != nil {
+			fmt.Printf("evaluation failed: %s\n", err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/flow"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+// parseExpression parses a dynaml expression optionally
+// enclosed in (( and )).
+func parseExpression(source string) (dynaml.Expression, error) {
+	source = strings.TrimSpace(source)
+	if strings.HasPrefix(source, "((") && strings.HasSuffix(source, "))") {
+		source = source[2 : len(source)-2]
+	}
+	return dynaml.Parse(source, nil, nil)
+}
+
+// evaluateExpression evaluates a dynaml expression with the
+// fields of the given document as binding.
+func evaluateExpression(outer dynaml.Binding, expr dynaml.Expression, doc yaml.Node) (interface{}, error) {
+	m := map[string]yaml.Node{}
+	if doc != nil {
+		if v, ok := doc.Value().(map[string]yaml.Node); ok {
+			m = v
+		}
+	}
+	binding := flow.NewNestedEnvironment(nil, "expression", outer).WithScope(m)
+	defer flow.CleanupEnvironment(binding)
+
+	value, info, ok := expr.Evaluate(binding, false)
+	if !ok {
+		return nil, fmt.Errorf("%s", info.Issue.Issue)
+	}
+	if _, ok := value.(dynaml.Expression); ok {
+		if info.Issue.Issue != "" {
+			return nil, fmt.Errorf("unresolved: %s", info.Issue.Issue)
+		}
+		return nil, fmt.Errorf("expression cannot be resolved")
+	}
+	return value, nil
+}
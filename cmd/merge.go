@@ -4,10 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/cloudfoundry-incubator/candiedyaml"
 	"github.com/mandelsoft/spiff/debug"
@@ -18,6 +18,7 @@ import (
 )
 
 var asJSON bool
+var outputFormat string
 var partial bool
 var outputPath string
 var selection []string
@@ -34,29 +35,89 @@ var mergeCmd = &cobra.Command{
 		if len(args) < 1 {
 			return errors.New("requires at least one arg")
 		}
+		if asJSON {
+			if cmd.Flags().Changed("format") && outputFormat != FORMAT_JSON {
+				return errors.New("json output cannot be combined with other output formats")
+			}
+			outputFormat = FORMAT_JSON
+		}
+		if _, ok := outputFormats[outputFormat]; !ok {
+			return fmt.Errorf("invalid output format %q", outputFormat)
+		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		merge(args[0], partial, asJSON, split, outputPath, selection, state, args[1:])
+		if !watch {
+			merge(args[0], partial, outputFormat, split, outputPath, selection, state, args[1:])
+			return
+		}
+		files := append([]string{}, args...)
+		for _, f := range []string{state, keyFile} {
+			if f != "" {
+				files = append(files, f)
+			}
+		}
+		watchFiles(files, watchInterval, func() {
+			merge(args[0], partial, outputFormat, split, outputPath, selection, state, args[1:])
+		})
 	},
 }
 
+// output formats of the merge command
+const (
+	FORMAT_YAML       = "yaml"
+	FORMAT_JSON       = "json"
+	FORMAT_TOML       = "toml"
+	FORMAT_ENV        = "env"
+	FORMAT_PROPERTIES = "properties"
+	FORMAT_HCL        = "hcl"
+)
+
+var outputFormats = map[string]func(yaml.Node) ([]byte, error){
+	FORMAT_YAML: func(node yaml.Node) ([]byte, error) {
+		return candiedyaml.Marshal(node)
+	},
+	FORMAT_JSON:       yaml.ToJSON,
+	FORMAT_TOML:       yaml.ToTOML,
+	FORMAT_ENV:        yaml.ToEnv,
+	FORMAT_PROPERTIES: yaml.ToProperties,
+	FORMAT_HCL:        yaml.ToHCL,
+}
+
 func init() {
 	rootCmd.AddCommand(mergeCmd)
 
 	mergeCmd.Flags().BoolVar(&asJSON, "json", false, "print output in json format")
 
+	mergeCmd.Flags().StringVar(&outputFormat, "format", FORMAT_YAML, "output format (yaml, json, toml, env, properties or hcl)")
+
 	mergeCmd.Flags().BoolVar(&debug.DebugFlag, "debug", false, "Print state info")
 
+	mergeCmd.Flags().BoolVar(&errorsJSON, "errors-json", false, "report unresolved nodes as json document on stderr")
+
 	mergeCmd.Flags().BoolVar(&partial, "partial", false, "Allow partial evaluation only")
 
 	mergeCmd.Flags().StringVar(&outputPath, "path", "", "output is taken from given path")
 
 	mergeCmd.Flags().BoolVar(&split, "split", false, "if the output is alist it will be split into separate documents")
 
+	mergeCmd.Flags().StringVar(&outputDir, "output-dir", "", "write the documents into separate files in the given directory")
+
+	mergeCmd.Flags().StringVar(&fileName, "file-name", "", "dynaml expression evaluated per document to determine its file name")
+
+	mergeCmd.Flags().BoolVar(&prune, "prune", false, "remove files written by a previous merge, which are not written anymore")
+
 	mergeCmd.Flags().StringVar(&state, "state", "", "select state file to maintain")
 
 	mergeCmd.Flags().StringArrayVar(&selection, "select", []string{}, "filter dedicated output fields")
+
+	mergeCmd.Flags().BoolVar(&watch, "watch", false, "merge again whenever an input file changes")
+
+	mergeCmd.Flags().DurationVar(&watchInterval, "watch-interval", time.Second, "interval for checking input files in watch mode")
+
+	addPolicyFlags(mergeCmd)
+
+	addKeyFileFlag(mergeCmd)
 }
 
 func fileExists(filename string) bool {
@@ -67,8 +128,9 @@ func fileExists(filename string) bool {
 	return !info.IsDir()
 }
 
-func merge(templateFilePath string, partial bool, json, split bool,
-	subpath string, selection []string, stateFilePath string, stubFilePaths []string) {
+// readTemplateAndStubs reads and parses the template documents and
+// the stubs for a merge. Standard input ("-") may be used once.
+func readTemplateAndStubs(templateFilePath string, stubFilePaths []string) ([]yaml.Node, []yaml.Node) {
 	var templateFile []byte
 	var err error
 	var stdin = false
@@ -81,23 +143,12 @@ func merge(templateFilePath string, partial bool, json, split bool,
 	}
 
 	if err != nil {
-		log.Fatalln(fmt.Sprintf("error reading template [%s]:", path.Clean(templateFilePath)), err)
+		fatal(fmt.Sprintf("error reading template [%s]:", path.Clean(templateFilePath)), err)
 	}
 
 	templateYAMLs, err := yaml.ParseMulti(templateFilePath, templateFile)
 	if err != nil {
-		log.Fatalln(fmt.Sprintf("error parsing template [%s]:", path.Clean(templateFilePath)), err)
-	}
-
-	var stateData []byte
-
-	if stateFilePath != "" {
-		if len(templateYAMLs) > 1 {
-			log.Fatalln(fmt.Sprintf("state handling not supported gor multi documents [%s]:", path.Clean(templateFilePath)), err)
-		}
-		if fileExists(stateFilePath) {
-			stateData, err = ioutil.ReadFile(stateFilePath)
-		}
+		fatal(fmt.Sprintf("error parsing template [%s]:", path.Clean(templateFilePath)), err)
 	}
 
 	stubs := []yaml.Node{}
@@ -107,7 +158,7 @@ func merge(templateFilePath string, partial bool, json, split bool,
 		var err error
 		if stubFilePath == "-" {
 			if stdin {
-				log.Fatalln(fmt.Sprintf("stdin cannot be used twice"))
+				fatal(fmt.Sprintf("stdin cannot be used twice"))
 			}
 			stubFile, err = ioutil.ReadAll(os.Stdin)
 			stdin = true
@@ -115,36 +166,52 @@ func merge(templateFilePath string, partial bool, json, split bool,
 			stubFile, err = ReadFile(stubFilePath)
 		}
 		if err != nil {
-			log.Fatalln(fmt.Sprintf("error reading stub [%s]:", path.Clean(stubFilePath)), err)
+			fatal(fmt.Sprintf("error reading stub [%s]:", path.Clean(stubFilePath)), err)
 		}
 
 		stubYAML, err := yaml.Parse(stubFilePath, stubFile)
 		if err != nil {
-			log.Fatalln(fmt.Sprintf("error parsing stub [%s]:", path.Clean(stubFilePath)), err)
+			fatal(fmt.Sprintf("error parsing stub [%s]:", path.Clean(stubFilePath)), err)
 		}
 
 		stubs = append(stubs, stubYAML)
 	}
+	return templateYAMLs, stubs
+}
+
+func merge(templateFilePath string, partial bool, format string, split bool,
+	subpath string, selection []string, stateFilePath string, stubFilePaths []string) {
+	templateYAMLs, stubs := readTemplateAndStubs(templateFilePath, stubFilePaths)
+
+	var stateData []byte
+	var err error
+
+	if stateFilePath != "" {
+		if len(templateYAMLs) > 1 {
+			fatal(fmt.Sprintf("state handling not supported gor multi documents [%s]:", path.Clean(templateFilePath)), err)
+		}
+		if fileExists(stateFilePath) {
+			stateData, err = ioutil.ReadFile(stateFilePath)
+		}
+	}
 
 	if stateData != nil {
 		stateYAML, err := yaml.Parse(stateFilePath, stateData)
 		if err != nil {
-			log.Fatalln(fmt.Sprintf("error parsing state [%s]:", path.Clean(stateFilePath)), err)
+			fatal(fmt.Sprintf("error parsing state [%s]:", path.Clean(stateFilePath)), err)
 		}
 		stubs = append(stubs, stateYAML)
 	}
 
-	legend := "\nerror classification:\n" +
-		" *: error in local dynaml expression\n" +
-		" @: dependent of or involved in a cycle\n" +
-		" -: depending on a node with an error"
+	outer := outerEnvironment()
+	defer flow.CleanupEnvironment(outer)
 
-	prepared, err := flow.PrepareStubs(nil, partial, stubs...)
+	prepared, err := flow.PrepareStubs(outer, partial, stubs...)
 	if !partial && err != nil {
-		log.Fatalln("error generating manifest:", err, legend)
+		failOnEvaluationError("error generating manifest:", err)
 	}
 
-	result := [][]byte{}
+	result := []output{}
 	count := 0
 	for no, templateYAML := range templateYAMLs {
 		doc := ""
@@ -152,11 +219,12 @@ func merge(templateFilePath string, partial bool, json, split bool,
 			doc = fmt.Sprintf(" (document %d)", no+1)
 		}
 		var bytes []byte
+		var node yaml.Node
 		if templateYAML.Value() != nil {
 			count++
-			flowed, err := flow.Apply(nil, templateYAML, prepared)
+			flowed, err := flow.Apply(outer, templateYAML, prepared)
 			if !partial && err != nil {
-				log.Fatalln(fmt.Sprintf("error generating manifest%s:", doc), err, legend)
+				failOnEvaluationError(fmt.Sprintf("error generating manifest%s:", doc), err)
 			}
 			if err != nil {
 				flowed = dynaml.ResetUnresolvedNodes(flowed)
@@ -165,13 +233,13 @@ func merge(templateFilePath string, partial bool, json, split bool,
 				comps := dynaml.PathComponents(subpath, false)
 				node, ok := yaml.FindR(true, flowed, comps...)
 				if !ok {
-					log.Fatalln(fmt.Sprintf("path %q not found%s", subpath, doc))
+					fatal(fmt.Sprintf("path %q not found%s", subpath, doc))
 				}
 				flowed = node
 			}
 			if stateFilePath != "" {
 				state := flow.Cleanup(flowed, flow.DiscardNonState)
-				json := json
+				json := format == FORMAT_JSON
 				if strings.HasSuffix(stateFilePath, ".yaml") || strings.HasSuffix(stateFilePath, ".yml") {
 					json = false
 				} else {
@@ -196,7 +264,7 @@ func merge(templateFilePath string, partial bool, json, split bool,
 					if old {
 						os.Rename(stateFilePath+".bak", stateFilePath)
 					}
-					log.Fatalln(fmt.Sprintf("cannot write state file %q", stateFilePath))
+					fatal(fmt.Sprintf("cannot write state file %q", stateFilePath))
 				}
 			}
 			if len(selection) > 0 {
@@ -205,7 +273,7 @@ func merge(templateFilePath string, partial bool, json, split bool,
 					comps := dynaml.PathComponents(p, false)
 					node, ok := yaml.FindR(true, flowed, comps...)
 					if !ok {
-						log.Fatalln(fmt.Sprintf("path %q not found%s", subpath, doc))
+						fatal(fmt.Sprintf("path %q not found%s", subpath, doc))
 					}
 					new[comps[len(comps)-1]] = node
 
@@ -215,38 +283,40 @@ func merge(templateFilePath string, partial bool, json, split bool,
 			if split {
 				if list, ok := flowed.Value().([]yaml.Node); ok {
 					for _, d := range list {
-						if json {
-							bytes, err = yaml.ToJSON(d)
-						} else {
-							bytes, err = candiedyaml.Marshal(d)
-						}
+						bytes, err = outputFormats[format](d)
 						if err != nil {
-							log.Fatalln(fmt.Sprintf("error marshalling manifest%s:", doc), err)
+							fatal(fmt.Sprintf("error marshalling manifest%s:", doc), err)
 						}
-						result = append(result, bytes)
+						result = append(result, output{d, bytes})
 					}
 					continue
 				}
 			}
-			if json {
-				bytes, err = yaml.ToJSON(flowed)
-			} else {
-				bytes, err = candiedyaml.Marshal(flowed)
-			}
+			bytes, err = outputFormats[format](flowed)
 			if err != nil {
-				log.Fatalln(fmt.Sprintf("error marshalling manifest%s:", doc), err)
+				fatal(fmt.Sprintf("error marshalling manifest%s:", doc), err)
 			}
+			node = flowed
+		}
+		result = append(result, output{node, bytes})
+	}
+
+	if outputDir != "" {
+		err := writeFiles(outer, result, format, outputDir, fileName, prune)
+		if err != nil {
+			fatal(err)
 		}
-		result = append(result, bytes)
+		return
 	}
 
-	for _, bytes := range result {
-		if !json && (len(result) > 1 || len(bytes) == 0) {
+	for _, o := range result {
+		bytes := o.data
+		if format == FORMAT_YAML && (len(result) > 1 || len(bytes) == 0) {
 			fmt.Println("---")
 		}
 		if bytes != nil {
 			fmt.Print(string(bytes))
-			if json {
+			if format == FORMAT_JSON {
 				fmt.Println()
 			}
 		}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	"github.com/spf13/cobra"
+
+	"github.com/mandelsoft/spiff/compare"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+var merge3Keys []string
+var merge3JSON bool
+var preferTheirs bool
+
+// merge3Cmd represents the merge3 command
+var merge3Cmd = &cobra.Command{
+	Use:     "merge3 <base> <ours> <theirs>",
+	Aliases: []string{"diff3"},
+	Short:   "Three-way merge of YAML documents",
+	Long: `Merge the changes of two documents (ours and theirs) relative to
+their common base document. Changes made in only one document are taken over.
+Nodes changed differently in both documents are reported as conflicts on
+stderr and the command exits with status 1. For conflicts the result contains
+the node of our document, or of their document if --theirs is given.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 3 {
+			return errors.New("requires three args")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		merge3(args[0], args[1], args[2])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(merge3Cmd)
+
+	merge3Cmd.Flags().StringArrayVar(&merge3Keys, "key", []string{}, "key field used to match list entries ([<path>=]<field>)")
+	merge3Cmd.Flags().BoolVar(&merge3JSON, "json", false, "print output in json format")
+	merge3Cmd.Flags().BoolVar(&preferTheirs, "theirs", false, "prefer their nodes for conflicts")
+}
+
+func readStream(kind, filePath string) []yaml.Node {
+	file, err := ReadFile(filePath)
+	if err != nil {
+		trouble(fmt.Sprintf("error reading %s [%s]:", kind, path.Clean(filePath)), err)
+	}
+	docs, err := yaml.ParseMulti(filePath, file)
+	if err != nil {
+		trouble(fmt.Sprintf("error parsing %s [%s]:", kind, path.Clean(filePath)), err)
+	}
+	return docs
+}
+
+func merge3(baseFilePath, oursFilePath, theirsFilePath string) {
+	bases := readStream("base", baseFilePath)
+	ours := readStream("ours", oursFilePath)
+	theirs := readStream("theirs", theirsFilePath)
+
+	if len(bases) != len(ours) || len(bases) != len(theirs) {
+		trouble(fmt.Sprintf("different number of documents (%d, %d, %d)", len(bases), len(ours), len(theirs)))
+	}
+
+	opts := &compare.Options{}
+	for _, k := range merge3Keys {
+		opts.AddKey(k)
+	}
+
+	found := false
+	for no := range bases {
+		merged, conflicts := compare.Merge3(bases[no], ours[no], theirs[no], opts, preferTheirs)
+		for _, c := range conflicts {
+			found = true
+			if len(bases) > 1 {
+				log.Printf("conflict in document %d: %s\n", no+1, c)
+			} else {
+				log.Printf("conflict in %s\n", c)
+			}
+		}
+
+		var bytes []byte
+		var err error
+		if merge3JSON {
+			bytes, err = yaml.ToJSON(merged)
+		} else {
+			if len(bases) > 1 || merged == nil || merged.Value() == nil {
+				fmt.Println("---")
+			}
+			if merged != nil && merged.Value() != nil {
+				bytes, err = candiedyaml.Marshal(merged)
+			}
+		}
+		if err != nil {
+			trouble("error marshalling document:", err)
+		}
+		fmt.Print(string(bytes))
+		if merge3JSON {
+			fmt.Println()
+		}
+	}
+	if found {
+		exit(EXIT_DIFFERENT)
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	"github.com/spf13/cobra"
+
+	"github.com/mandelsoft/spiff/compare"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+var patchJSON bool
+
+// patchCmd represents the patch command
+var patchCmd = &cobra.Command{
+	Use:   "patch <document> <patch>",
+	Short: "Apply a patch to a YAML document",
+	Long: `Apply a JSON Patch (RFC 6902) or the JSON output of the diff command
+to a document stream. Paths can be given as JSON pointers or as dynaml
+references. If the expected old values do not match or operations cannot be
+applied, all conflicts are reported and the document is not changed.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("requires two args")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		patch(args[0], args[1], patchJSON)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(patchCmd)
+
+	patchCmd.Flags().BoolVar(&patchJSON, "json", false, "print output in json format")
+}
+
+func patch(docFilePath, patchFilePath string, json bool) {
+	var docFile []byte
+	var err error
+
+	if docFilePath == "-" {
+		docFile, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		docFile, err = ReadFile(docFilePath)
+	}
+	if err != nil {
+		log.Fatalln(fmt.Sprintf("error reading document [%s]:", path.Clean(docFilePath)), err)
+	}
+
+	docYAMLs, err := yaml.ParseMulti(docFilePath, docFile)
+	if err != nil {
+		log.Fatalln(fmt.Sprintf("error parsing document [%s]:", path.Clean(docFilePath)), err)
+	}
+
+	patchFile, err := ReadFile(patchFilePath)
+	if err != nil {
+		log.Fatalln(fmt.Sprintf("error reading patch [%s]:", path.Clean(patchFilePath)), err)
+	}
+
+	patchYAML, err := yaml.Parse(patchFilePath, patchFile)
+	if err != nil {
+		log.Fatalln(fmt.Sprintf("error parsing patch [%s]:", path.Clean(patchFilePath)), err)
+	}
+
+	patches, err := compare.ParsePatch(patchYAML)
+	if err != nil {
+		log.Fatalln(fmt.Sprintf("invalid patch [%s]:", path.Clean(patchFilePath)), err)
+	}
+
+	result, err := compare.ApplyStream(docYAMLs, patches)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	for _, doc := range result {
+		var bytes []byte
+		if json {
+			bytes, err = yaml.ToJSON(doc)
+		} else {
+			if len(result) > 1 || doc.Value() == nil {
+				fmt.Println("---")
+			}
+			if doc.Value() == nil {
+				continue
+			}
+			bytes, err = candiedyaml.Marshal(doc)
+		}
+		if err != nil {
+			log.Fatalln("error marshalling document:", err)
+		}
+		fmt.Print(string(bytes))
+		if json {
+			fmt.Println()
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/dynaml/passwd"
+	"github.com/mandelsoft/spiff/flow"
+	"github.com/mandelsoft/spiff/vfs"
+	"github.com/spf13/cobra"
+)
+
+var keyFile string
+
+// fileSystem is the file system used for the processing, if not
+// set the file system of the operating system is used
+var fileSystem vfs.FileSystem
+
+var sandbox bool
+var allowCommands []string
+var allowDirectories []string
+var allowURLs []string
+var allowEnvVars []string
+
+func addPolicyFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&sandbox, "sandbox", false, "deny access to commands, files, URLs and environment variables not explicitly allowed")
+	cmd.Flags().StringArrayVar(&allowCommands, "allow-command", []string{}, "command allowed in sandbox mode")
+	cmd.Flags().StringArrayVar(&allowDirectories, "allow-dir", []string{}, "directory tree allowed for file access in sandbox mode")
+	cmd.Flags().StringArrayVar(&allowURLs, "allow-url", []string{}, "URL (path tree) allowed in sandbox mode")
+	cmd.Flags().StringArrayVar(&allowEnvVars, "allow-env", []string{}, "environment variable allowed in sandbox mode")
+}
+
+func addKeyFileFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&keyFile, "key-file", "", "read the encryption key from the given file")
+}
+
+// encryptionKey determines the encryption key given by a key file or
+// the environment.
+func encryptionKey() string {
+	var key string
+	var err error
+	if keyFile != "" {
+		key, err = passwd.ReadKeyFile(keyFile)
+	} else {
+		key, err = passwd.EnvironmentKey()
+	}
+	if err != nil {
+		fatal(err)
+	}
+	return key
+}
+
+// outerEnvironment provides an outer environment for the processing,
+// if a policy, key file or file system is configured, otherwise nil
+// is returned.
+// Any allow option implies the sandbox mode.
+func outerEnvironment() dynaml.Binding {
+	var policy *dynaml.Policy
+	if sandbox || len(allowCommands)+len(allowDirectories)+len(allowURLs)+len(allowEnvVars) > 0 {
+		policy = &dynaml.Policy{
+			Commands:    allowCommands,
+			Directories: allowDirectories,
+			URLs:        allowURLs,
+			EnvVars:     allowEnvVars,
+		}
+	}
+	if policy == nil && keyFile == "" && fileSystem == nil {
+		return nil
+	}
+	state := flow.NewState(encryptionKey()).SetPolicy(policy).SetFileSystem(fileSystem)
+	return flow.NewEnvironmentWithState(nil, "", state)
+}
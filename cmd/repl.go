@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	"github.com/spf13/cobra"
+
+	"github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/flow"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+var historyFile string
+
+// replCmd represents the repl command
+var replCmd = &cobra.Command{
+	Use:   "repl [<template> [<stub> ...]]",
+	Short: "Interactively evaluate dynaml expressions",
+	Long: `Read dynaml expressions from stdin and print their values as YAML.
+The expressions are evaluated with the merge of the optional template and
+stubs as binding. Additional stubs can be added with the commands :load and
+:set. Enter :help to list all commands.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		r := newREPL(os.Stdout)
+		if len(args) > 0 {
+			if err := r.load(args[0], args[1:]); err != nil {
+				log.Fatalln(err)
+			}
+		}
+		if historyFile != "" {
+			if err := r.readHistory(historyFile); err != nil {
+				log.Fatalln(err)
+			}
+		}
+		r.run(os.Stdin, isTerminal(os.Stdin))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(replCmd)
+
+	replCmd.Flags().StringVar(&historyFile, "history", "", "file to keep the history of entered lines")
+
+	addPolicyFlags(replCmd)
+
+	addKeyFileFlag(replCmd)
+}
+
+const replHelp = `Enter a dynaml expression (with or without (( and ))) to evaluate it.
+Commands:
+  :load <file>         add a stub file with highest precedence
+  :set <path> <yaml>   add a stub setting the given path to a yaml value
+  :show                print the current binding
+  :history             list the history
+  !<n>                 evaluate entry <n> of the history again
+  :help                print this help
+  :quit                leave the repl
+`
+
+type repl struct {
+	out      io.Writer
+	outer    dynaml.Binding
+	template yaml.Node
+	stubs    []yaml.Node
+	root     yaml.Node
+	history  []string
+	histFile *os.File
+}
+
+func newREPL(out io.Writer) *repl {
+	return &repl{out: out, outer: outerEnvironment()}
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (r *repl) readHistory(file string) error {
+	if data, err := ReadFile(file); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if line != "" {
+				r.history = append(r.history, line)
+			}
+		}
+	}
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("cannot open history file [%s]: %s", path.Clean(file), err)
+	}
+	r.histFile = f
+	return nil
+}
+
+func (r *repl) addHistory(line string) {
+	r.history = append(r.history, line)
+	if r.histFile != nil {
+		fmt.Fprintln(r.histFile, line)
+	}
+}
+
+func readYAML(kind, file string) (yaml.Node, error) {
+	data, err := ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s [%s]: %s", kind, path.Clean(file), err)
+	}
+	node, err := yaml.Parse(file, data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s [%s]: %s", kind, path.Clean(file), err)
+	}
+	return node, nil
+}
+
+// load reads the template and the stubs given on the command line.
+// An incomplete binding is only reported, the repl continues with the
+// partially resolved binding.
+func (r *repl) load(template string, stubs []string) error {
+	node, err := readYAML("template", template)
+	if err != nil {
+		return err
+	}
+	r.template = node
+	for _, file := range stubs {
+		node, err := readYAML("stub", file)
+		if err != nil {
+			return err
+		}
+		r.stubs = append(r.stubs, node)
+	}
+	if err := r.update(); err != nil {
+		fmt.Fprintf(r.out, "warning: %s\n", err)
+	}
+	return nil
+}
+
+// loadStub adds a stub file. Stubs added later have precedence
+// over the stubs given on the command line.
+func (r *repl) loadStub(file string, precedence bool) error {
+	node, err := readYAML("stub", file)
+	if err != nil {
+		return err
+	}
+	return r.addStub(node, precedence)
+}
+
+func (r *repl) addStub(node yaml.Node, precedence bool) error {
+	if precedence {
+		r.stubs = append([]yaml.Node{node}, r.stubs...)
+	} else {
+		r.stubs = append(r.stubs, node)
+	}
+	return r.update()
+}
+
+// set adds a stub setting the value for the given path
+func (r *repl) set(ref, value string) error {
+	node, err := yaml.Parse("set", []byte(value))
+	if err != nil {
+		return fmt.Errorf("invalid value: %s", err)
+	}
+	comps := dynaml.PathComponents(ref, false)
+	if len(comps) == 0 {
+		return fmt.Errorf("path required")
+	}
+	for i := len(comps) - 1; i >= 0; i-- {
+		node = yaml.NewNode(map[string]yaml.Node{comps[i]: node}, "set")
+	}
+	return r.addStub(node, true)
+}
+
+// update merges the template with the stubs to provide the binding.
+// Fields of the stubs not present in the template are merged, also.
+func (r *repl) update() error {
+	fields := map[string]yaml.Node{}
+	if r.template != nil {
+		if m, ok := r.template.Value().(map[string]yaml.Node); ok {
+			for k, v := range m {
+				fields[k] = v
+			}
+		}
+	}
+	for _, s := range r.stubs {
+		if m, ok := s.Value().(map[string]yaml.Node); ok {
+			for k := range m {
+				if _, ok := fields[k]; !ok {
+					fields[k] = yaml.NewNode("(( merge ))", "repl")
+				}
+			}
+		}
+	}
+	stubs := make([]yaml.Node, len(r.stubs))
+	copy(stubs, r.stubs)
+	root, err := flow.Cascade(r.outer, yaml.NewNode(fields, "repl"), true, stubs...)
+	if root != nil {
+		r.root = dynaml.ResetUnresolvedNodes(root)
+	}
+	if err != nil {
+		return fmt.Errorf("binding incomplete: %s", err)
+	}
+	return nil
+}
+
+func (r *repl) print(value interface{}) {
+	data, err := candiedyaml.Marshal(yaml.NewNode(value, "repl"))
+	if err != nil {
+		fmt.Fprintf(r.out, "error: %s\n", err)
+		return
+	}
+	fmt.Fprint(r.out, string(data))
+}
+
+func (r *repl) evaluate(source string) {
+	expr, err := parseExpression(source)
+	if err != nil {
+		fmt.Fprintf(r.out, "error: %s\n", err)
+		return
+	}
+	value, err := evaluateExpression(r.outer, expr, r.root)
+	if err != nil {
+		fmt.Fprintf(r.out, "error: %s\n", err)
+		return
+	}
+	r.print(value)
+}
+
+// execute handles a single input line. It returns false
+// if the repl should be left.
+func (r *repl) execute(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return true
+	}
+	if strings.HasPrefix(line, "!") {
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 1 || n > len(r.history) {
+			fmt.Fprintf(r.out, "error: invalid history entry %q\n", line[1:])
+			return true
+		}
+		line = r.history[n-1]
+		fmt.Fprintln(r.out, line)
+	}
+	r.addHistory(line)
+
+	if !strings.HasPrefix(line, ":") {
+		r.evaluate(line)
+		return true
+	}
+
+	fields := strings.Fields(line)
+	var err error
+	switch fields[0] {
+	case ":quit", ":q":
+		return false
+	case ":help":
+		fmt.Fprint(r.out, replHelp)
+	case ":load":
+		if len(fields) != 2 {
+			err = errors.New("usage: :load <file>")
+		} else {
+			err = r.loadStub(fields[1], true)
+		}
+	case ":set":
+		parts := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(line, ":set")), " ", 2)
+		if len(parts) != 2 {
+			err = errors.New("usage: :set <path> <yaml>")
+		} else {
+			err = r.set(parts[0], parts[1])
+		}
+	case ":show":
+		if r.root != nil {
+			r.print(r.root.Value())
+		}
+	case ":history":
+		for i, h := range r.history[:len(r.history)-1] {
+			fmt.Fprintf(r.out, "%4d  %s\n", i+1, h)
+		}
+	default:
+		err = fmt.Errorf("unknown command %s, use :help", fields[0])
+	}
+	if err != nil {
+		fmt.Fprintf(r.out, "error: %s\n", err)
+	}
+	return true
+}
+
+// run reads and executes lines until the input is
+// exhausted or the repl is left.
+func (r *repl) run(in io.Reader, prompt bool) {
+	defer flow.CleanupEnvironment(r.outer)
+	if r.histFile != nil {
+		defer r.histFile.Close()
+	}
+
+	scanner := bufio.NewScanner(in)
+	for {
+		if prompt {
+			fmt.Fprint(r.out, "spiff> ")
+		}
+		if !scanner.Scan() || !r.execute(scanner.Text()) {
+			break
+		}
+	}
+	if prompt {
+		fmt.Fprintln(r.out)
+	}
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+var outputDir string
+var fileName string
+var prune bool
+
+// output is a processed document together with its marshalled content
+type output struct {
+	node yaml.Node
+	data []byte
+}
+
+// manifestFile is the file in the output directory listing the files
+// written by the last merge.
+const manifestFile = ".spiff-generated"
+
+// writeFiles writes the documents into separate files in the output
+// directory. The file names are determined by a dynaml expression
+// evaluated with the document as root. Without expression the documents
+// are numbered. Files with unchanged content are not touched. The written
+// files are recorded in the manifest file of the output directory. If
+// prune is set, files recorded by a previous merge, which are not written
+// anymore, are removed. Other files are never touched.
+func writeFiles(outer dynaml.Binding, docs []output, format, dir, pattern string, prune bool) error {
+	var expr dynaml.Expression
+	if pattern != "" {
+		var err error
+		expr, err = parseExpression(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid file name expression %q: %s", pattern, err)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create output directory %q: %s", dir, err)
+	}
+
+	written := map[string]bool{}
+	names := []string{}
+	data := [][]byte{}
+	for i, doc := range docs {
+		if doc.node == nil || doc.data == nil {
+			continue
+		}
+		name := fmt.Sprintf("%d.%s", i+1, format)
+		if expr != nil {
+			var err error
+			name, err = evaluateFileName(outer, expr, doc.node)
+			if err != nil {
+				return fmt.Errorf("document %d: %s", i+1, err)
+			}
+		}
+		name = filepath.Clean(name)
+		if filepath.IsAbs(name) || name == "." || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("document %d: file name %q outside of output directory", i+1, name)
+		}
+		if name == manifestFile {
+			return fmt.Errorf("document %d: file name %q is reserved", i+1, name)
+		}
+		if written[name] {
+			return fmt.Errorf("document %d: duplicate file name %q", i+1, name)
+		}
+		written[name] = true
+		names = append(names, name)
+		data = append(data, doc.data)
+	}
+
+	for i, name := range names {
+		path := filepath.Join(dir, name)
+		if old, err := ioutil.ReadFile(path); err == nil && bytes.Equal(old, data[i]) {
+			continue
+		}
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(path, data[i], 0644)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot write %q: %s", path, err)
+		}
+	}
+
+	manifest := filepath.Join(dir, manifestFile)
+	if prune {
+		old, err := ioutil.ReadFile(manifest)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot read %q: %s", manifest, err)
+		}
+		for _, name := range strings.Split(string(old), "\n") {
+			name = filepath.Clean(filepath.FromSlash(name))
+			if written[name] || !generatedFileName(name) {
+				continue
+			}
+			path := filepath.Join(dir, name)
+			if info, err := os.Lstat(path); err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("cannot prune %q: %s", path, err)
+			}
+		}
+	}
+
+	list := ""
+	for _, name := range sortedNames(written) {
+		list += filepath.ToSlash(name) + "\n"
+	}
+	if old, err := ioutil.ReadFile(manifest); err == nil && string(old) == list {
+		return nil
+	}
+	if err := ioutil.WriteFile(manifest, []byte(list), 0644); err != nil {
+		return fmt.Errorf("cannot write %q: %s", manifest, err)
+	}
+	return nil
+}
+
+// generatedFileName checks whether a file name recorded in the manifest
+// may denote a generated file. Names outside of the output directory or
+// in dot directories (like .git) are never pruned.
+func generatedFileName(name string) bool {
+	if name == "." || name == manifestFile || filepath.IsAbs(name) {
+		return false
+	}
+	for _, step := range strings.Split(filepath.Dir(name), string(filepath.Separator)) {
+		if strings.HasPrefix(step, ".") && step != "." {
+			return false
+		}
+	}
+	return name != ".." && !strings.HasPrefix(name, ".."+string(filepath.Separator))
+}
+
+func sortedNames(names map[string]bool) []string {
+	result := []string{}
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// evaluateFileName evaluates the file name expression for a document
+func evaluateFileName(outer dynaml.Binding, expr dynaml.Expression, doc yaml.Node) (string, error) {
+	value, err := evaluateExpression(outer, expr, doc)
+	if err != nil {
+		return "", fmt.Errorf("cannot evaluate file name: %s", err)
+	}
+	name, ok := value.(string)
+	if !ok || name == "" {
+		return "", fmt.Errorf("file name expression must yield a non-empty string")
+	}
+	return name, nil
+}
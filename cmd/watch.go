@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/mandelsoft/spiff/vfs"
+)
+
+var watch bool
+var watchInterval time.Duration
+
+// watchFailure is raised instead of terminating the
+// process, if a merge fails in watch mode
+type watchFailure struct {
+	code int
+}
+
+// fileStamp describes the state of a watched file
+type fileStamp struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+func stamp(name string) fileStamp {
+	info, err := os.Stat(name)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{true, info.ModTime(), info.Size()}
+}
+
+// watchFiles repeatedly calls the given function, whenever one of the
+// given files or of the files read by the function has changed. Errors
+// of the function are reported without terminating the watch loop.
+func watchFiles(files []string, interval time.Duration, f func()) {
+	for _, name := range files {
+		if name == "-" {
+			log.Fatalln("stdin cannot be watched")
+		}
+	}
+
+	fatal = func(v ...interface{}) {
+		log.Println(v...)
+		panic(watchFailure{EXIT_ERROR})
+	}
+	exit = func(code int) {
+		panic(watchFailure{code})
+	}
+
+	for {
+		tracker := vfs.NewTrackingFileSystem(vfs.OSFileSystem)
+		fileSystem = tracker
+		runWatched(f)
+		fileSystem = nil
+
+		stamps := map[string]fileStamp{}
+		for _, name := range append(append([]string{}, files...), tracker.Files()...) {
+			stamps[name] = stamp(name)
+		}
+		log.Printf("watching %d files for changes\n", len(stamps))
+
+	wait:
+		for {
+			time.Sleep(interval)
+			for name, s := range stamps {
+				if stamp(name) != s {
+					log.Printf("%s changed\n", name)
+					break wait
+				}
+			}
+		}
+	}
+}
+
+// runWatched executes a function and recovers from a watch failure
+func runWatched(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			failure, ok := r.(watchFailure)
+			if !ok {
+				panic(r)
+			}
+			log.Printf("merge failed with exit code %d\n", failure.code)
+		}
+	}()
+	f()
+}
@@ -3,7 +3,11 @@ package compare
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/mandelsoft/spiff/flow"
 	"github.com/mandelsoft/spiff/yaml"
 )
 
@@ -12,26 +16,113 @@ type Diff struct {
 	B yaml.Node
 
 	Path []string
+	// Pointer is the location of the difference in the first document
+	// as sequence of JSON pointer tokens. For entries added to a list
+	// the last token is "-".
+	Pointer []string
+}
+
+// DefaultKey is the field used to match list entries if no other
+// key field is configured.
+const DefaultKey = "name"
+
+// Options configures the matching of list entries. Entries of a list
+// providing a key field are matched by its value, all other entries
+// are matched by their index. Like for dynaml references the path
+// component of a list entry is the value of its key field, prefixed
+// by <field>: for key fields other than DefaultKey.
+//
+// The key field for a list is determined by the key tag (key:<field>)
+// used in the list entries, a path specific key, the global key and
+// finally DefaultKey.
+type Options struct {
+	// Key is the global key field.
+	Key string
+	// Keys maps list paths to key fields. Path components are
+	// separated by dots, a * matches any path component.
+	Keys map[string]string
+	// Ignore lists path patterns for nodes excluded from the comparison.
+	Ignore []string
+	// Only lists path patterns for nodes to compare. If empty,
+	// all nodes are compared.
+	Only []string
+}
+
+// AddKey adds a key specification of the form [<path>=]<field>.
+// Without a path the global key field is set.
+func (o *Options) AddKey(spec string) {
+	i := strings.LastIndex(spec, "=")
+	if i < 0 {
+		o.Key = spec
+		return
+	}
+	if o.Keys == nil {
+		o.Keys = map[string]string{}
+	}
+	o.Keys[spec[:i]] = spec[i+1:]
+}
+
+func (o *Options) keyFor(path []string) string {
+	if o == nil {
+		return DefaultKey
+	}
+	// the pattern with the fewest wildcards wins
+	found := ""
+	wildcards := -1
+	for pattern := range o.Keys {
+		if matchPath(strings.Split(pattern, "."), path) {
+			n := strings.Count(pattern, "*")
+			if wildcards < 0 || n < wildcards || (n == wildcards && pattern < found) {
+				found, wildcards = pattern, n
+			}
+		}
+	}
+	if wildcards >= 0 {
+		return o.Keys[found]
+	}
+	if o.Key != "" {
+		return o.Key
+	}
+	return DefaultKey
+}
+
+func matchPath(pattern []string, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && p != path[i] {
+			return false
+		}
+	}
+	return true
 }
 
 func Compare(a, b yaml.Node) []Diff {
-	return compare(a, b, []string{})
+	return CompareWithOptions(a, b, nil)
+}
+
+// CompareWithOptions compares two documents using dedicated
+// options for matching list entries.
+func CompareWithOptions(a, b yaml.Node, opts *Options) []Diff {
+	return opts.filter(compare(a, b, []string{}, []string{}, opts))
 }
 
-func compare(a, b yaml.Node, path []string) []Diff {
-	mismatch := Diff{A: a, B: b, Path: path}
+func compare(a, b yaml.Node, path []string, ptr []string, opts *Options) []Diff {
+	mismatch := Diff{A: a, B: b, Path: path, Pointer: ptr}
 
 	switch av := a.Value().(type) {
 	case map[string]yaml.Node:
 		switch bv := b.Value().(type) {
 		case map[string]yaml.Node:
-			return compareMap(av, bv, path)
+			return compareMap(av, bv, path, ptr, opts)
 
 		case []yaml.Node:
-			toMap := listToMap(bv)
+			bv, key := processKeyTags(bv, opts.keyFor(path))
+			toMap := listToMap(bv, key)
 
 			if toMap != nil {
-				return compareMap(av, toMap, path)
+				return compareMap(av, toMap, path, ptr, opts)
 			} else {
 				return []Diff{mismatch}
 			}
@@ -43,7 +134,7 @@ func compare(a, b yaml.Node, path []string) []Diff {
 	case []yaml.Node:
 		switch bv := b.Value().(type) {
 		case []yaml.Node:
-			return compareList(av, bv, path)
+			return compareList(av, bv, path, ptr, opts)
 		default:
 			return []Diff{mismatch}
 		}
@@ -57,31 +148,65 @@ func compare(a, b yaml.Node, path []string) []Diff {
 		}
 
 		if av != b.Value() {
-			return []Diff{Diff{A: a, B: b, Path: path}}
+			return []Diff{mismatch}
 		}
 	}
 
 	return []Diff{}
 }
 
-func listToMap(list []yaml.Node) map[string]yaml.Node {
+// processKeyTags removes the key tags from the list entries and
+// returns the key field to use for the list.
+func processKeyTags(list []yaml.Node, key string) ([]yaml.Node, string) {
+	result := make([]yaml.Node, len(list))
+	tagged := ""
+	for i, e := range list {
+		e, k := flow.ProcessKeyTag(e)
+		if k != "" {
+			tagged = k
+		}
+		result[i] = e
+	}
+	if tagged != "" {
+		key = tagged
+	}
+	return result, key
+}
+
+// keyValue returns the string representation of the key field of
+// a list entry. Only string and integer values are used as keys.
+func keyValue(node yaml.Node, key string) (string, bool) {
+	m, ok := node.Value().(map[string]yaml.Node)
+	if !ok {
+		return "", false
+	}
+	v, ok := m[key]
+	if !ok || v == nil {
+		return "", false
+	}
+	switch k := v.Value().(type) {
+	case string:
+		return k, true
+	case int64:
+		return fmt.Sprintf("%d", k), true
+	}
+	return "", false
+}
+
+func listToMap(list []yaml.Node, key string) map[string]yaml.Node {
 	toMap := make(map[string]yaml.Node)
 
 	for _, val := range list {
-		name, ok := yaml.FindString(val, "name")
-		if !ok {
-			return nil
-		}
-
-		asMap, ok := val.Value().(map[string]yaml.Node)
+		name, ok := keyValue(val, key)
 		if !ok {
 			return nil
 		}
 
+		asMap := val.Value().(map[string]yaml.Node)
 		newMap := make(map[string]yaml.Node)
-		for key, val := range asMap {
-			if key != "name" {
-				newMap[key] = val
+		for k, val := range asMap {
+			if k != key {
+				newMap[k] = val
 			}
 		}
 
@@ -91,126 +216,128 @@ func listToMap(list []yaml.Node) map[string]yaml.Node {
 	return toMap
 }
 
-func compareMap(a, b map[string]yaml.Node, path []string) []Diff {
+// compareMap reports the differences of two maps ordered by
+// key to provide a stable output.
+func compareMap(a, b map[string]yaml.Node, path []string, ptr []string, opts *Options) []Diff {
 	diff := []Diff{}
 
-	for key, aval := range a {
+	for _, key := range sortedKeys(a) {
+		aval := a[key]
 		bval, present := b[key]
 		if present {
-			diff = append(diff, compare(aval, bval, addPath(path, key))...)
+			diff = append(diff, compare(aval, bval, addPath(path, key), addPath(ptr, key), opts)...)
 		} else {
-			diff = append(diff, Diff{A: aval, B: nil, Path: addPath(path, key)})
+			diff = append(diff, Diff{A: aval, B: nil, Path: addPath(path, key), Pointer: addPath(ptr, key)})
 		}
 	}
 
-	for key, bval := range b {
-		_, present := a[key]
-		if !present {
-			diff = append(diff, Diff{A: nil, B: bval, Path: addPath(path, key)})
-			continue
+	for _, key := range sortedKeys(b) {
+		if _, present := a[key]; !present {
+			diff = append(diff, Diff{A: nil, B: b[key], Path: addPath(path, key), Pointer: addPath(ptr, key)})
 		}
 	}
 
 	return diff
 }
 
-func compareList(a, b []yaml.Node, path []string) []Diff {
+func sortedKeys(m map[string]yaml.Node) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func compareList(a, b []yaml.Node, path []string, ptr []string, opts *Options) []Diff {
 	diff := []Diff{}
 
-	if len(path) == 1 && path[0] == "jobs" {
-		return compareJobs(a, b, path)
+	key := opts.keyFor(path)
+	a, akey := processKeyTags(a, key)
+	b, bkey := processKeyTags(b, key)
+	if akey != key {
+		key = akey
+	} else {
+		key = bkey
 	}
 
+	matched := make([]bool, len(b))
 	for index, aval := range a {
-		key, bval, found := findByNameOrIndex(aval, b, index)
+		name, bindex, found := findByKeyOrIndex(aval, b, index, key)
 
 		if !found {
-			diff = append(diff, Diff{A: aval, B: nil, Path: addPath(path, key)})
+			diff = append(diff, Diff{A: aval, B: nil, Path: addPath(path, name), Pointer: addPath(ptr, strconv.Itoa(index))})
 			continue
 		}
 
-		diff = append(diff, compare(aval, bval, addPath(path, key))...)
+		matched[bindex] = true
+		diff = append(diff, compare(aval, b[bindex], addPath(path, name), addPath(ptr, strconv.Itoa(index)), opts)...)
 	}
 
 	for index, bval := range b {
-		key := fmt.Sprintf("[%d]", index)
-
-		if len(a) <= index {
-			diff = append(diff, Diff{A: nil, B: bval, Path: addPath(path, key)})
+		if matched[index] {
 			continue
 		}
-	}
-
-	return diff
-}
-
-func compareJobs(ajobs, bjobs []yaml.Node, path []string) []Diff {
-	return compareMap(jobMap(ajobs), jobMap(bjobs), path)
-}
-
-func jobMap(jobs []yaml.Node) map[string]yaml.Node {
-	byName := make(map[string]yaml.Node)
-
-	for index, job := range jobs {
-		attrs, ok := job.Value().(map[string]yaml.Node)
-		attrs["index"] = yaml.NewNode(index, job.SourceName())
-
-		name, ok := yaml.FindString(job, "name")
+		name, ok := entryName(bval, key)
 		if !ok {
-			panic("job without string name")
+			name = fmt.Sprintf("[%d]", index)
 		}
-
-		byName[name] = yaml.NewNode(attrs, job.SourceName())
+		diff = append(diff, Diff{A: nil, B: bval, Path: addPath(path, name), Pointer: addPath(ptr, "-")})
 	}
 
-	return byName
+	return diff
 }
 
-func findByNameOrIndex(node yaml.Node, others []yaml.Node, index int) (string, yaml.Node, bool) {
-	name, ok := yaml.FindString(node, "name")
+// findByKeyOrIndex finds the matching list entry for a list entry. Entries
+// with a key field are matched by its value, all other entries are
+// matched by their index.
+func findByKeyOrIndex(node yaml.Node, others []yaml.Node, index int, key string) (string, int, bool) {
+	name, ok := entryName(node, key)
 	if !ok {
-		return findByIndex(others, index)
+		return findByIndex(others, index, key)
 	}
 
-	key, node, found := findByName(name, others)
-	if !found {
-		return findByIndex(others, index)
+	for i, other := range others {
+		otherName, ok := entryName(other, key)
+		if ok && otherName == name {
+			return name, i, true
+		}
 	}
 
-	return key, node, true
+	return name, -1, false
 }
 
-func findByName(name string, nodes []yaml.Node) (string, yaml.Node, bool) {
-	for _, node := range nodes {
-		otherName, ok := yaml.FindString(node, "name")
-		if !ok {
-			continue
-		}
-
-		if otherName == name {
-			return name, node, true
-		}
+// entryName returns the path component for a list entry with a key field.
+// Like for dynaml references, the key field is added for key fields
+// other than the default key.
+func entryName(node yaml.Node, key string) (string, bool) {
+	name, ok := keyValue(node, key)
+	if ok && key != DefaultKey {
+		name = key + ":" + name
 	}
-
-	return "", nil, false
+	return name, ok
 }
 
-func findByIndex(nodes []yaml.Node, index int) (string, yaml.Node, bool) {
-	key := fmt.Sprintf("[%d]", index)
+func findByIndex(nodes []yaml.Node, index int, key string) (string, int, bool) {
+	name := fmt.Sprintf("[%d]", index)
 
 	if len(nodes) <= index {
-		return key, nil, false
+		return name, -1, false
+	}
+	if _, ok := keyValue(nodes[index], key); ok {
+		return name, -1, false
 	}
 
-	return key, nodes[index], true
+	return name, index, true
 }
 
 // cannot use straight append for this, as it will overwrite
 // previous steps, since it reuses the slice
 //
 // e.g. with inital path A:
-//    append(A, "a")
-//    append(A, "b")
+//
+//	append(A, "a")
+//	append(A, "b")
 //
 // will result in all previous A/a paths becoming A/b
 func addPath(path []string, steps ...string) []string {
@@ -1,8 +1,12 @@
 package compare
 
 import (
+	"encoding/json"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/spiff/yaml"
 )
 
 var _ = Describe("Diffing YAML", func() {
@@ -27,7 +31,7 @@ foo: 2
 `)
 
 			It("reports one difference with the key as the path", func() {
-				Expect(Compare(a, b)).To(Equal([]Diff{
+				Expect(Compare(a, b)).To(EqualDiffs([]Diff{
 					{
 						A:    parseYAML("1"),
 						B:    parseYAML("2"),
@@ -37,6 +41,39 @@ foo: 2
 			})
 		})
 
+		Context("when there are differences for several keys", func() {
+			a := parseYAML(`
+---
+c: 1
+a: 1
+d: 1
+b:
+  q: 1
+  p: 1
+`)
+
+			b := parseYAML(`
+---
+e: 1
+c: 2
+b:
+  p: 2
+  q: 2
+a: 2
+`)
+
+			It("reports the differences ordered by key", func() {
+				Expect(Compare(a, b)).To(EqualDiffs([]Diff{
+					{A: parseYAML("1"), B: parseYAML("2"), Path: []string{"a"}},
+					{A: parseYAML("1"), B: parseYAML("2"), Path: []string{"b", "p"}},
+					{A: parseYAML("1"), B: parseYAML("2"), Path: []string{"b", "q"}},
+					{A: parseYAML("1"), B: parseYAML("2"), Path: []string{"c"}},
+					{A: parseYAML("1"), B: nil, Path: []string{"d"}},
+					{A: nil, B: parseYAML("1"), Path: []string{"e"}},
+				}))
+			})
+		})
+
 		Context("when there is a nested difference in value", func() {
 			a := parseYAML(`
 ---
@@ -51,7 +88,7 @@ foo:
 `)
 
 			It("reports one difference for the nested difference, not the wholistic one", func() {
-				Expect(Compare(a, b)).To(Equal([]Diff{
+				Expect(Compare(a, b)).To(EqualDiffs([]Diff{
 					{
 						A:    parseYAML("1"),
 						B:    parseYAML("2"),
@@ -74,7 +111,7 @@ foo: 2
 `)
 
 			It("reports one difference with the different nodes", func() {
-				Expect(Compare(a, b)).To(Equal([]Diff{
+				Expect(Compare(a, b)).To(EqualDiffs([]Diff{
 					{
 						A:    parseYAML("bar: 1"),
 						B:    parseYAML("2"),
@@ -95,7 +132,7 @@ foo: 1
 `)
 
 			It("reports one difference", func() {
-				Expect(Compare(a, b)).To(Equal([]Diff{
+				Expect(Compare(a, b)).To(EqualDiffs([]Diff{
 					{
 						A:    parseYAML("1"),
 						B:    nil,
@@ -105,6 +142,36 @@ foo: 1
 			})
 		})
 
+		Context("when an entry is inserted into a named list", func() {
+			a := parseYAML(`
+---
+- name: a
+  value: foo
+- name: c
+  value: bar
+`)
+
+			b := parseYAML(`
+---
+- name: a
+  value: foo
+- name: b
+  value: new
+- name: c
+  value: bar
+`)
+
+			It("reports only the inserted entry", func() {
+				Expect(Compare(a, b)).To(EqualDiffs([]Diff{
+					Diff{
+						A:    nil,
+						B:    parseYAML("name: b\nvalue: new\n"),
+						Path: []string{"b"},
+					},
+				}))
+			})
+		})
+
 		Context("when there is a value missing from A", func() {
 			a := parseYAML(`
 --- {}
@@ -116,7 +183,7 @@ foo: 1
 `)
 
 			It("reports one difference", func() {
-				Expect(Compare(a, b)).To(Equal([]Diff{
+				Expect(Compare(a, b)).To(EqualDiffs([]Diff{
 					Diff{
 						A:    nil,
 						B:    parseYAML("1"),
@@ -139,21 +206,21 @@ bar: 2
 			It("reports both differences", func() {
 				diff := Compare(a, b)
 
-				Expect(diff).To(ContainElement(
+				Expect(diff).To(ContainElement(EqualDiff(
 					Diff{
 						A:    parseYAML("1"),
 						B:    nil,
 						Path: []string{"foo"},
 					},
-				))
+				)))
 
-				Expect(diff).To(ContainElement(
+				Expect(diff).To(ContainElement(EqualDiff(
 					Diff{
 						A:    nil,
 						B:    parseYAML("2"),
 						Path: []string{"bar"},
 					},
-				))
+				)))
 			})
 		})
 
@@ -184,7 +251,7 @@ foo:
 `)
 
 				It("reports no differences", func() {
-					Expect(Compare(a, b)).To(Equal([]Diff{
+					Expect(Compare(a, b)).To(EqualDiffs([]Diff{
 						{
 							A:    parseYAML("1"),
 							B:    parseYAML("2"),
@@ -209,7 +276,7 @@ foo:
 `)
 
 			It("reports one difference with the index in the path", func() {
-				Expect(Compare(a, b)).To(Equal([]Diff{
+				Expect(Compare(a, b)).To(EqualDiffs([]Diff{
 					{
 						A:    parseYAML("1"),
 						B:    parseYAML("2"),
@@ -232,7 +299,7 @@ foo:
 `)
 
 			It("reports one difference with the index in the path", func() {
-				Expect(Compare(a, b)).To(Equal([]Diff{
+				Expect(Compare(a, b)).To(EqualDiffs([]Diff{
 					{
 						A:    parseYAML("[hello, world]"),
 						B:    parseYAML("42"),
@@ -261,26 +328,8 @@ jobs:
   value: foo
 `)
 
-			It("reports their indices as different", func() {
-				diff := Compare(a, b)
-
-				Expect(diff).To(HaveLen(2))
-
-				Expect(diff).To(ContainElement(
-					Diff{
-						A:    parseYAML("0"),
-						B:    parseYAML("1"),
-						Path: []string{"jobs", "a", "index"},
-					},
-				))
-
-				Expect(diff).To(ContainElement(
-					Diff{
-						A:    parseYAML("1"),
-						B:    parseYAML("0"),
-						Path: []string{"jobs", "b", "index"},
-					},
-				))
+			It("reports no differences", func() {
+				Expect(Compare(a, b)).To(BeEmpty())
 			})
 		})
 
@@ -304,10 +353,10 @@ jobs:
 			It("reports it as different", func() {
 				diff := Compare(a, b)
 
-				Expect(diff).To(Equal([]Diff{
+				Expect(diff).To(EqualDiffs([]Diff{
 					Diff{
 						A:    nil,
-						B:    parseYAML("name: b\nvalue: bar\nindex: 1\n"),
+						B:    parseYAML("name: b\nvalue: bar\n"),
 						Path: []string{"jobs", "b"},
 					},
 				}))
@@ -357,7 +406,7 @@ foo:
 `)
 
 			It("reports each difference", func() {
-				Expect(Compare(a, b)).To(Equal([]Diff{
+				Expect(Compare(a, b)).To(EqualDiffs([]Diff{
 					Diff{
 						A:    parseYAML("2"),
 						B:    nil,
@@ -390,7 +439,7 @@ foo:
 `)
 
 			It("reports one difference", func() {
-				Expect(Compare(a, b)).To(Equal([]Diff{
+				Expect(Compare(a, b)).To(EqualDiffs([]Diff{
 					Diff{
 						A:    nil,
 						B:    parseYAML("2"),
@@ -400,4 +449,492 @@ foo:
 			})
 		})
 	})
+
+	Describe("list keys", func() {
+		a := parseYAML(`
+---
+ports:
+- containerPort: 80
+  protocol: TCP
+- containerPort: 443
+  protocol: TCP
+items:
+- id: a
+  value: 1
+`)
+
+		b := parseYAML(`
+---
+ports:
+- containerPort: 8080
+  protocol: TCP
+- containerPort: 80
+  protocol: UDP
+- containerPort: 443
+  protocol: TCP
+items:
+- id: a
+  value: 2
+`)
+
+		It("matches entries by index without configured keys", func() {
+			diffs := Compare(a, b)
+			Expect(diffs).To(HaveLen(5))
+			Expect(diffs).To(ContainElement(
+				EqualDiff(Diff{
+					A:    parseYAML("1"),
+					B:    parseYAML("2"),
+					Path: []string{"items", "[0]", "value"},
+				}),
+			))
+		})
+
+		It("matches entries by a global key", func() {
+			diffs := CompareWithOptions(a, b, &Options{Key: "id"})
+			Expect(diffs).To(HaveLen(5))
+			Expect(diffs).To(ContainElement(
+				EqualDiff(Diff{
+					A:    parseYAML("1"),
+					B:    parseYAML("2"),
+					Path: []string{"items", "id:a", "value"},
+				}),
+			))
+		})
+
+		It("matches entries by path specific keys", func() {
+			opts := &Options{}
+			opts.AddKey("id")
+			opts.AddKey("ports=containerPort")
+			Expect(CompareWithOptions(a, b, opts)).To(ConsistOf(
+				EqualDiff(Diff{
+					A:    parseYAML("TCP"),
+					B:    parseYAML("UDP"),
+					Path: []string{"ports", "containerPort:80", "protocol"},
+				}),
+				EqualDiff(Diff{
+					A:    nil,
+					B:    parseYAML("containerPort: 8080\nprotocol: TCP\n"),
+					Path: []string{"ports", "containerPort:8080"},
+				}),
+				EqualDiff(Diff{
+					A:    parseYAML("1"),
+					B:    parseYAML("2"),
+					Path: []string{"items", "id:a", "value"},
+				}),
+			))
+		})
+
+		It("supports wildcards in key paths", func() {
+			opts := &Options{Keys: map[string]string{"*": "containerPort"}}
+			Expect(CompareWithOptions(a, b, opts)).To(ContainElement(
+				EqualDiff(Diff{
+					A:    parseYAML("TCP"),
+					B:    parseYAML("UDP"),
+					Path: []string{"ports", "containerPort:80", "protocol"},
+				}),
+			))
+		})
+
+		It("honors key tags", func() {
+			a := parseYAML(`
+---
+- key:id: a
+  value: 1
+- id: b
+  value: 2
+`)
+
+			b := parseYAML(`
+---
+- id: b
+  value: 2
+- id: a
+  value: 3
+`)
+			Expect(Compare(a, b)).To(EqualDiffs([]Diff{
+				Diff{
+					A:    parseYAML("1"),
+					B:    parseYAML("3"),
+					Path: []string{"id:a", "value"},
+				},
+			}))
+		})
+	})
+
+	Describe("path filters", func() {
+		a := parseYAML(`
+---
+metadata:
+  name: app
+  generation: 1
+  annotations:
+    checksum/config: abc
+spec:
+  replicas: 1
+  containers:
+  - name: web
+    image: web:1
+    resources:
+      cpu: 1
+`)
+
+		b := parseYAML(`
+---
+metadata:
+  name: app
+  generation: 2
+  annotations:
+    checksum/config: def
+spec:
+  replicas: 2
+  containers:
+  - name: web
+    image: web:2
+    resources:
+      cpu: 2
+`)
+
+		It("ignores matching paths and their children", func() {
+			diffs := CompareWithOptions(a, b, &Options{Ignore: []string{"metadata", "spec.containers.*.resources"}})
+			Expect(diffs).To(ConsistOf(
+				EqualDiff(Diff{
+					A:    parseYAML("1"),
+					B:    parseYAML("2"),
+					Path: []string{"spec", "replicas"},
+				}),
+				EqualDiff(Diff{
+					A:    parseYAML("web:1"),
+					B:    parseYAML("web:2"),
+					Path: []string{"spec", "containers", "web", "image"},
+				}),
+			))
+		})
+
+		It("matches any depth with **", func() {
+			diffs := CompareWithOptions(a, b, &Options{Ignore: []string{"**.cpu", "**.generation", "**.annotations"}})
+			Expect(diffs).To(HaveLen(2))
+		})
+
+		It("compares only matching paths", func() {
+			diffs := CompareWithOptions(a, b, &Options{Only: []string{"spec.containers.*.image"}})
+			Expect(diffs).To(EqualDiffs([]Diff{
+				Diff{
+					A:    parseYAML("web:1"),
+					B:    parseYAML("web:2"),
+					Path: []string{"spec", "containers", "web", "image"},
+				},
+			}))
+		})
+
+		It("reports added parents of selected paths", func() {
+			a := parseYAML(`
+---
+list:
+- a
+`)
+			b := parseYAML(`
+---
+list:
+- a
+- b
+other: x
+`)
+			diffs := CompareWithOptions(a, b, &Options{Only: []string{"list.[*]"}})
+			Expect(diffs).To(EqualDiffs([]Diff{
+				Diff{
+					B:    parseYAML("b"),
+					Path: []string{"list", "[1]"},
+				},
+			}))
+		})
+
+		It("parses ignore files", func() {
+			Expect(ParsePatterns([]byte("# comment\nmetadata\n\n  spec.*.cpu \n"))).To(Equal([]string{"metadata", "spec.*.cpu"}))
+		})
+	})
+
+	Describe("json patch", func() {
+		It("orders the operations to keep indices valid", func() {
+			a := parseYAML(`
+---
+list:
+- a
+- b
+- c
+- d
+`)
+			b := parseYAML(`
+---
+list:
+- x
+- b
+`)
+			patch, err := JSONPatch(Compare(a, b))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(patch).To(Equal([]PatchOperation{
+				{Op: OP_TEST, Path: "/list/0", Value: "a"},
+				{Op: OP_REPLACE, Path: "/list/0", Value: "x"},
+				{Op: OP_TEST, Path: "/list/3", Value: "d"},
+				{Op: OP_REMOVE, Path: "/list/3"},
+				{Op: OP_TEST, Path: "/list/2", Value: "c"},
+				{Op: OP_REMOVE, Path: "/list/2"},
+			}))
+		})
+
+		It("detects conflicting documents with test operations", func() {
+			a := parseYAML(`
+---
+foo: bar
+list:
+- a
+- b
+`)
+			b := parseYAML(`
+---
+foo: baz
+list:
+- a
+`)
+			ops, err := JSONPatch(Compare(a, b))
+			Expect(err).NotTo(HaveOccurred())
+
+			data, err := json.Marshal(ops)
+			Expect(err).NotTo(HaveOccurred())
+			patches, err := ParsePatch(parseYAML(string(data)))
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := Apply(a, patches[0])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(Compare(result, b)).To(BeEmpty())
+
+			_, err = Apply(parseYAML(`
+---
+foo: other
+list:
+- a
+- b
+`), patches[0])
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(`patch conflicts:
+  test /foo: expected value "bar", but found "other"`))
+		})
+
+		It("escapes pointer tokens", func() {
+			Expect(Pointer([]string{"a/b", "c~d"})).To(Equal("/a~1b/c~0d"))
+		})
+	})
+
+	Describe("patching", func() {
+		doc := parseYAML(`
+---
+foo: bar
+list:
+- name: a
+  value: 1
+- name: b
+  value: 2
+`)
+
+		patch := func(source string) [][]PatchOperation {
+			patches, err := ParsePatch(parseYAML(source))
+			Expect(err).NotTo(HaveOccurred())
+			return patches
+		}
+
+		It("applies JSON patches", func() {
+			result, err := Apply(doc, patch(`
+- op: replace
+  path: /foo
+  value: baz
+- op: add
+  path: /list/1
+  value:
+    name: c
+- op: copy
+  from: /list/0/value
+  path: /copied
+- op: move
+  from: /list/2
+  path: /moved
+- op: test
+  path: /moved/value
+  value: 2
+`)[0])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(Compare(result, parseYAML(`
+---
+foo: baz
+copied: 1
+list:
+- name: a
+  value: 1
+- name: c
+moved:
+  name: b
+  value: 2
+`))).To(BeEmpty())
+		})
+
+		It("accepts dynaml references as paths", func() {
+			result, err := Apply(doc, patch(`
+- op: replace
+  path: list.b.value
+  value: 3
+- op: remove
+  path: list.[0]
+- op: add
+  path: list.name:d
+  value:
+    name: d
+`)[0])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(Compare(result, parseYAML(`
+---
+foo: bar
+list:
+- name: b
+  value: 3
+- name: d
+`))).To(BeEmpty())
+		})
+
+		It("reports all conflicts", func() {
+			_, err := Apply(doc, patch(`
+- path: foo
+  op: replace
+  old: other
+  new: baz
+- op: remove
+  path: list.c
+- op: test
+  path: /list/0/value
+  value: 1
+- op: add
+  path: /foo/bar
+  value: 1
+`)[0])
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(`patch conflicts:
+  replace foo: expected value "other", but found "bar"
+  remove list.c: no list entry with name "c"
+  add /foo/bar: /foo: no map or list`))
+		})
+
+		It("applies difference records to document streams", func() {
+			a := parseYAML(`
+---
+list: [ 1, 2, 3 ]
+`)
+			b := parseYAML(`
+---
+list: [ 1 ]
+`)
+			records, err := Records(2, Compare(a, b))
+			Expect(err).NotTo(HaveOccurred())
+			data, err := json.Marshal(records)
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := ApplyStream([]yaml.Node{doc, a}, patch(string(data)))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result[0]).To(Equal(doc))
+			Expect(Compare(result[1], b)).To(BeEmpty())
+		})
+	})
+
+	Describe("three-way merge", func() {
+		base := parseYAML(`
+---
+replicas: 1
+image: app:1
+labels:
+  app: a
+containers:
+- name: a
+  port: 80
+- name: b
+  port: 81
+`)
+
+		It("takes over changes of both documents", func() {
+			ours := parseYAML(`
+---
+replicas: 3
+image: app:1
+labels:
+  app: a
+  team: x
+containers:
+- name: b
+  port: 81
+- name: a
+  port: 80
+  debug: true
+`)
+			theirs := parseYAML(`
+---
+replicas: 1
+image: app:2
+labels:
+  app: a
+containers:
+- name: a
+  port: 8080
+- name: c
+  port: 82
+`)
+			merged, conflicts := Merge3(base, ours, theirs, nil, false)
+			Expect(conflicts).To(BeEmpty())
+			Expect(Compare(merged, parseYAML(`
+---
+replicas: 3
+image: app:2
+labels:
+  app: a
+  team: x
+containers:
+- name: a
+  port: 8080
+  debug: true
+- name: c
+  port: 82
+`))).To(BeEmpty())
+			list, _ := yaml.Find(merged, "containers")
+			Expect(list.Value()).To(HaveLen(2))
+		})
+
+		It("reports conflicts", func() {
+			ours := parseYAML(`
+---
+replicas: 2
+image: app:1
+labels:
+  app: a
+containers:
+- name: a
+  port: 80
+`)
+			theirs := parseYAML(`
+---
+replicas: 3
+image: app:1
+labels:
+  app: a
+containers:
+- name: a
+  port: 80
+- name: b
+  port: 90
+`)
+			merged, conflicts := Merge3(base, ours, theirs, nil, false)
+			Expect(conflicts).To(HaveLen(2))
+			Expect(conflicts[0].String()).To(Equal(`containers.b: base {"name":"b","port":81}, ours <missing>, theirs {"name":"b","port":90}`))
+			Expect(conflicts[1].String()).To(Equal(`replicas: base 1, ours 2, theirs 3`))
+			replicas, _ := yaml.FindInt(merged, "replicas")
+			Expect(replicas).To(Equal(int64(2)))
+
+			merged, _ = Merge3(base, ours, theirs, nil, true)
+			replicas, _ = yaml.FindInt(merged, "replicas")
+			Expect(replicas).To(Equal(int64(3)))
+		})
+	})
 })
@@ -0,0 +1,114 @@
+package compare
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/mandelsoft/spiff/dynaml"
+)
+
+// Path patterns are dynaml references, whose components may contain
+// the wildcard *, matching any sequence of characters. The component
+// ** matches any number of path components, [*] matches any list index.
+
+// ParsePatterns reads path patterns from the content of an ignore file.
+// Every line contains a pattern, empty lines and lines starting
+// with # are ignored.
+func ParsePatterns(data []byte) []string {
+	patterns := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns
+}
+
+// filter removes the differences excluded by the
+// Ignore and Only patterns of the options.
+func (o *Options) filter(diffs []Diff) []Diff {
+	if o == nil || (len(o.Ignore) == 0 && len(o.Only) == 0) {
+		return diffs
+	}
+	result := []Diff{}
+	for _, d := range diffs {
+		if matchesPrefix(o.Ignore, d.Path) {
+			continue
+		}
+		if len(o.Only) > 0 && !matchesPrefix(o.Only, d.Path) && !containsMatch(o.Only, d.Path) {
+			continue
+		}
+		result = append(result, d)
+	}
+	return result
+}
+
+// matchesPrefix checks whether the path or one of its
+// parents matches one of the patterns.
+func matchesPrefix(patterns []string, path []string) bool {
+	for _, p := range patterns {
+		pattern := dynaml.PathComponents(p, false)
+		for i := len(path); i >= 0; i-- {
+			if matchPattern(pattern, path[:i], false) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsMatch checks whether a path matching one of the
+// patterns may be located below the given path.
+func containsMatch(patterns []string, path []string) bool {
+	for _, p := range patterns {
+		if matchPattern(dynaml.PathComponents(p, false), path, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern matches a path against a pattern. If prefix is set,
+// the path must match only a prefix of the pattern.
+func matchPattern(pattern []string, path []string, prefix bool) bool {
+	if len(path) == 0 {
+		return len(pattern) == 0 || prefix
+	}
+	if len(pattern) == 0 {
+		return false
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchPattern(pattern[1:], path[i:], prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	return matchComponent(pattern[0], path[0]) && matchPattern(pattern[1:], path[1:], prefix)
+}
+
+// matchComponent matches a path component against a pattern,
+// where * matches any sequence of characters.
+func matchComponent(pattern, comp string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == comp
+	}
+	if !strings.HasPrefix(comp, parts[0]) {
+		return false
+	}
+	comp = comp[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(comp, part)
+		if i < 0 {
+			return false
+		}
+		comp = comp[i+len(part):]
+	}
+	return strings.HasSuffix(comp, parts[len(parts)-1])
+}
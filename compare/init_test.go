@@ -5,6 +5,7 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
 
 	"github.com/mandelsoft/spiff/yaml"
 )
@@ -26,3 +27,48 @@ func parseYAMLFrom(source string, name string) yaml.Node {
 
 	return parsed
 }
+
+// EqualDiffs matches diffs independently of the source positions
+// of the compared nodes.
+func EqualDiffs(expected []Diff) types.GomegaMatcher {
+	return WithTransform(withoutPositions, Equal(withoutPositions(expected)))
+}
+
+// EqualDiff matches a single diff independently of the source positions
+// of the compared nodes.
+func EqualDiff(expected Diff) types.GomegaMatcher {
+	return WithTransform(diffWithoutPositions, Equal(diffWithoutPositions(expected)))
+}
+
+func withoutPositions(diffs []Diff) []Diff {
+	result := []Diff{}
+	for _, d := range diffs {
+		result = append(result, diffWithoutPositions(d))
+	}
+	return result
+}
+
+func diffWithoutPositions(d Diff) Diff {
+	return Diff{A: nodeWithoutPositions(d.A), B: nodeWithoutPositions(d.B), Path: d.Path}
+}
+
+func nodeWithoutPositions(node yaml.Node) yaml.Node {
+	if node == nil {
+		return nil
+	}
+	switch v := node.Value().(type) {
+	case map[string]yaml.Node:
+		m := map[string]yaml.Node{}
+		for k, e := range v {
+			m[k] = nodeWithoutPositions(e)
+		}
+		return yaml.NewNode(m, node.SourceName())
+	case []yaml.Node:
+		l := []yaml.Node{}
+		for _, e := range v {
+			l = append(l, nodeWithoutPositions(e))
+		}
+		return yaml.NewNode(l, node.SourceName())
+	}
+	return yaml.NewNode(node.Value(), node.SourceName())
+}
@@ -0,0 +1,201 @@
+package compare
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+// MergeConflict describes a node changed differently in both
+// documents of a three-way merge. Nodes missing in a document are nil.
+type MergeConflict struct {
+	Path   []string
+	Base   yaml.Node
+	Ours   yaml.Node
+	Theirs yaml.Node
+}
+
+func (c MergeConflict) String() string {
+	return fmt.Sprintf("%s: base %s, ours %s, theirs %s", strings.Join(c.Path, "."),
+		describeNode(c.Base), describeNode(c.Ours), describeNode(c.Theirs))
+}
+
+func describeNode(node yaml.Node) string {
+	if node == nil {
+		return "<missing>"
+	}
+	v, err := yaml.Normalize(node)
+	if err != nil {
+		return fmt.Sprintf("%v", node.Value())
+	}
+	return describeValue(v)
+}
+
+// Merge3 merges the changes of two documents (ours and theirs) relative
+// to their common base. Changes made only in one document are taken over.
+// For nodes changed differently in both documents a conflict is reported,
+// the result contains the node of the preferred document. Maps are merged
+// per field and lists are merged per entry, if all entries provide a key
+// field according to the options. Other lists are merged per index if
+// they have the same length in all documents.
+func Merge3(base, ours, theirs yaml.Node, opts *Options, preferTheirs bool) (yaml.Node, []MergeConflict) {
+	m := &merger{opts: opts, preferTheirs: preferTheirs}
+	return m.merge(base, ours, theirs, []string{}), m.conflicts
+}
+
+type merger struct {
+	opts         *Options
+	preferTheirs bool
+	conflicts    []MergeConflict
+}
+
+func (m *merger) equal(a, b yaml.Node) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return len(CompareWithOptions(a, b, m.opts)) == 0
+}
+
+func (m *merger) conflict(base, ours, theirs yaml.Node, path []string) yaml.Node {
+	m.conflicts = append(m.conflicts, MergeConflict{path, base, ours, theirs})
+	if m.preferTheirs {
+		return theirs
+	}
+	return ours
+}
+
+func (m *merger) merge(base, ours, theirs yaml.Node, path []string) yaml.Node {
+	switch {
+	case m.equal(ours, theirs):
+		return ours
+	case m.equal(base, ours):
+		return theirs
+	case m.equal(base, theirs):
+		return ours
+	case ours == nil || theirs == nil:
+		return m.conflict(base, ours, theirs, path)
+	}
+
+	switch ov := ours.Value().(type) {
+	case map[string]yaml.Node:
+		tv, ok := theirs.Value().(map[string]yaml.Node)
+		if !ok {
+			break
+		}
+		bv := map[string]yaml.Node{}
+		if base != nil {
+			if v, ok := base.Value().(map[string]yaml.Node); ok {
+				bv = v
+			}
+		}
+		return yaml.SubstituteNode(m.mergeMap(bv, ov, tv, path), ours)
+
+	case []yaml.Node:
+		tv, ok := theirs.Value().([]yaml.Node)
+		if !ok {
+			break
+		}
+		bv := []yaml.Node{}
+		if base != nil {
+			if v, ok := base.Value().([]yaml.Node); ok {
+				bv = v
+			}
+		}
+		if result, ok := m.mergeList(bv, ov, tv, path); ok {
+			return yaml.SubstituteNode(result, ours)
+		}
+	}
+	return m.conflict(base, ours, theirs, path)
+}
+
+func (m *merger) mergeMap(base, ours, theirs map[string]yaml.Node, path []string) map[string]yaml.Node {
+	result := map[string]yaml.Node{}
+	keys := map[string]bool{}
+	for _, l := range []map[string]yaml.Node{base, ours, theirs} {
+		for k := range l {
+			keys[k] = true
+		}
+	}
+	sorted := []string{}
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	for _, k := range sorted {
+		n := m.merge(base[k], ours[k], theirs[k], addPath(path, k))
+		if n != nil {
+			result[k] = n
+		}
+	}
+	return result
+}
+
+func (m *merger) mergeList(base, ours, theirs []yaml.Node, path []string) ([]yaml.Node, bool) {
+	key := m.opts.keyFor(path)
+	for _, l := range []*[]yaml.Node{&base, &ours, &theirs} {
+		var k string
+		*l, k = processKeyTags(*l, key)
+		if k != key {
+			key = k
+		}
+	}
+
+	bm, bkeys, bok := keyedEntries(base, key)
+	om, okeys, ook := keyedEntries(ours, key)
+	tm, tkeys, tok := keyedEntries(theirs, key)
+	if !bok || !ook || !tok {
+		if len(base) != len(ours) || len(base) != len(theirs) {
+			return nil, false
+		}
+		result := []yaml.Node{}
+		for i := range base {
+			n := m.merge(base[i], ours[i], theirs[i], addPath(path, fmt.Sprintf("[%d]", i)))
+			if n != nil {
+				result = append(result, n)
+			}
+		}
+		return result, true
+	}
+
+	// the order of our list is kept, new entries of their list are appended
+	order := okeys
+	for _, k := range append(tkeys, bkeys...) {
+		if _, ok := om[k]; !ok {
+			order = append(order, k)
+			om[k] = nil
+		}
+	}
+	result := []yaml.Node{}
+	for _, k := range order {
+		name := k
+		if key != DefaultKey {
+			name = key + ":" + k
+		}
+		n := m.merge(bm[k], om[k], tm[k], addPath(path, name))
+		if n != nil {
+			result = append(result, n)
+		}
+	}
+	return result, true
+}
+
+// keyedEntries maps the list entries by the value of their key
+// field, if all entries provide unique key values.
+func keyedEntries(list []yaml.Node, key string) (map[string]yaml.Node, []string, bool) {
+	result := map[string]yaml.Node{}
+	keys := []string{}
+	for _, e := range list {
+		k, ok := keyValue(e, key)
+		if !ok {
+			return nil, nil, false
+		}
+		if _, ok := result[k]; ok {
+			return nil, nil, false
+		}
+		result[k] = e
+		keys = append(keys, k)
+	}
+	return result, keys, true
+}
@@ -0,0 +1,184 @@
+package compare
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+// operations of a JSON Patch (RFC 6902), the first three
+// are used to describe differences
+const (
+	OP_ADD     = "add"
+	OP_REMOVE  = "remove"
+	OP_REPLACE = "replace"
+	OP_MOVE    = "move"
+	OP_COPY    = "copy"
+	OP_TEST    = "test"
+)
+
+// Op returns the operation required to change the
+// first document according to the second one.
+func (d Diff) Op() string {
+	switch {
+	case d.A == nil:
+		return OP_ADD
+	case d.B == nil:
+		return OP_REMOVE
+	default:
+		return OP_REPLACE
+	}
+}
+
+// Record is the machine readable description of a difference.
+type Record struct {
+	Document int         `json:"document,omitempty"`
+	Path     string      `json:"path"`
+	Op       string      `json:"op"`
+	Old      interface{} `json:"old,omitempty"`
+	New      interface{} `json:"new,omitempty"`
+}
+
+// Records describes a list of differences as records. A document
+// number greater than zero is added to the records.
+func Records(document int, diffs []Diff) ([]Record, error) {
+	result := []Record{}
+	for _, d := range diffs {
+		old, err := yaml.Normalize(d.A)
+		if err != nil {
+			return nil, err
+		}
+		new, err := yaml.Normalize(d.B)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, Record{
+			Document: document,
+			Path:     strings.Join(d.Path, "."),
+			Op:       d.Op(),
+			Old:      old,
+			New:      new,
+		})
+	}
+	return result, nil
+}
+
+// PatchOperation is an operation of a JSON Patch (RFC 6902). Additionally
+// to the standard fields an operation may describe the expected old value
+// of the addressed node.
+type PatchOperation struct {
+	Op    string
+	Path  string
+	From  string
+	Value interface{}
+	// Old is the expected old value, it is checked if HasOld is set.
+	Old    interface{}
+	HasOld bool
+}
+
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{"op": o.Op, "path": o.Path}
+	switch o.Op {
+	case OP_MOVE, OP_COPY:
+		m["from"] = o.From
+	case OP_REMOVE:
+	default:
+		m["value"] = o.Value
+	}
+	if o.HasOld {
+		m["old"] = o.Old
+	}
+	return json.Marshal(m)
+}
+
+// Pointer returns the JSON pointer (RFC 6901) for a sequence of tokens.
+func Pointer(tokens []string) string {
+	s := ""
+	for _, t := range tokens {
+		s += "/" + strings.Replace(strings.Replace(t, "~", "~0", -1), "/", "~1", -1)
+	}
+	return s
+}
+
+// JSONPatch converts a list of differences into a JSON Patch transforming
+// the first compared document into the second one. Entries added to
+// lists are appended. Because list entries matched by a key field are
+// compared independently of their order, the order of such lists is
+// not adjusted by the patch. The operations are ordered such that
+// they do not influence the indices used by later operations. Every
+// replacement and removal is preceded by a test operation for the old
+// value, so that conflicting documents are detected when applying the
+// patch.
+func JSONPatch(diffs []Diff) ([]PatchOperation, error) {
+	ops := []PatchOperation{}
+	for _, d := range diffs {
+		value, err := yaml.Normalize(d.B)
+		if err != nil {
+			return nil, err
+		}
+		old, err := yaml.Normalize(d.A)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, PatchOperation{Op: d.Op(), Path: Pointer(d.Pointer), Value: value, Old: old})
+	}
+	result := []PatchOperation{}
+	for _, o := range orderOperations(ops) {
+		if o.Op != OP_ADD {
+			result = append(result, PatchOperation{Op: OP_TEST, Path: o.Path, Value: o.Old})
+		}
+		o.Old = nil
+		result = append(result, o)
+	}
+	return result, nil
+}
+
+// orderOperations orders replacements, additions and removals
+// such that they do not influence the indices used by later
+// operations: replacements are followed by additions and removals
+// with decreasing indices.
+func orderOperations(ops []PatchOperation) []PatchOperation {
+	var replace, add, remove []PatchOperation
+	for _, o := range ops {
+		switch o.Op {
+		case OP_ADD:
+			add = append(add, o)
+		case OP_REMOVE:
+			remove = append(remove, o)
+		default:
+			replace = append(replace, o)
+		}
+	}
+	sort.SliceStable(remove, func(i, j int) bool {
+		a, _ := pathTokens(remove[i].Path)
+		b, _ := pathTokens(remove[j].Path)
+		return comparePointer(a, b) > 0
+	})
+	return append(append(replace, add...), remove...)
+}
+
+// comparePointer compares pointer token sequences, numeric tokens are
+// compared numerically and a prefix is less than a longer sequence.
+func comparePointer(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		ai, aerr := strconv.Atoi(strings.Trim(a[i], "[]"))
+		bi, berr := strconv.Atoi(strings.Trim(b[i], "[]"))
+		if aerr == nil && berr == nil {
+			if ai < bi {
+				return -1
+			}
+			return 1
+		}
+		if a[i] < b[i] {
+			return -1
+		}
+		return 1
+	}
+	return len(a) - len(b)
+}
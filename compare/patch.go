@@ -0,0 +1,510 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+// Conflict describes an operation of a patch that could not be applied.
+type Conflict struct {
+	Document int
+	Op       string
+	Path     string
+	Message  string
+}
+
+func (c Conflict) String() string {
+	doc := ""
+	if c.Document > 0 {
+		doc = fmt.Sprintf("document %d: ", c.Document)
+	}
+	return fmt.Sprintf("%s%s %s: %s", doc, c.Op, c.Path, c.Message)
+}
+
+// PatchError reports the conflicts found while applying a patch.
+type PatchError struct {
+	Conflicts []Conflict
+}
+
+func (e *PatchError) Error() string {
+	s := "patch conflicts:"
+	for _, c := range e.Conflicts {
+		s += "\n  " + c.String()
+	}
+	return s
+}
+
+// ParsePatch reads the patches for the documents of a stream. It accepts
+//   - a JSON Patch (RFC 6902) for the first document,
+//   - a list of JSON Patches, one for every document,
+//   - a list of difference records as provided by Records.
+//
+// Paths may be given as JSON pointer or as dynaml reference.
+// The expected old value of difference records is checked when
+// the patch is applied.
+func ParsePatch(node yaml.Node) ([][]PatchOperation, error) {
+	list, ok := node.Value().([]yaml.Node)
+	if !ok {
+		return nil, fmt.Errorf("patch must be a list")
+	}
+
+	patches := [][]PatchOperation{}
+	if len(list) > 0 {
+		if _, ok := list[0].Value().([]yaml.Node); ok {
+			for i, e := range list {
+				l, ok := e.Value().([]yaml.Node)
+				if !ok {
+					return nil, fmt.Errorf("document %d: patch must be a list", i+1)
+				}
+				ops := []PatchOperation{}
+				for j, o := range l {
+					op, _, err := parseOperation(o, j)
+					if err != nil {
+						return nil, fmt.Errorf("document %d: %s", i+1, err)
+					}
+					ops = append(ops, op)
+				}
+				patches = append(patches, ops)
+			}
+			return patches, nil
+		}
+	}
+
+	// difference records are ordered to keep the list indices valid,
+	// while the operations of JSON patches are applied in the given order
+	records := false
+	for _, e := range list {
+		if m, ok := e.Value().(map[string]yaml.Node); ok {
+			_, isNew := m["new"]
+			_, isOld := m["old"]
+			_, isValue := m["value"]
+			_, isFrom := m["from"]
+			if isValue || isFrom {
+				records = false
+				break
+			}
+			records = records || isNew || isOld
+		}
+	}
+	for i, e := range list {
+		op, doc, err := parseOperation(e, i)
+		if err != nil {
+			return nil, err
+		}
+		if doc == 0 {
+			doc = 1
+		}
+		for len(patches) < doc {
+			patches = append(patches, []PatchOperation{})
+		}
+		patches[doc-1] = append(patches[doc-1], op)
+	}
+	if len(patches) == 0 {
+		patches = append(patches, []PatchOperation{})
+	}
+	if records {
+		for i, p := range patches {
+			patches[i] = orderOperations(p)
+		}
+	}
+	return patches, nil
+}
+
+// parseOperation parses a patch operation or a difference record
+// and returns the document number given by the record or 0.
+func parseOperation(node yaml.Node, no int) (PatchOperation, int, error) {
+	op := PatchOperation{}
+	doc := 0
+	m, ok := node.Value().(map[string]yaml.Node)
+	if !ok {
+		return op, doc, fmt.Errorf("operation %d: map required", no+1)
+	}
+	for k, v := range m {
+		var err error
+		switch k {
+		case "op":
+			op.Op, err = stringField(k, v)
+		case "path":
+			op.Path, err = stringField(k, v)
+		case "from":
+			op.From, err = stringField(k, v)
+		case "value", "new":
+			op.Value, err = yaml.Normalize(v)
+		case "old":
+			op.Old, err = yaml.Normalize(v)
+			op.HasOld = true
+		case "document":
+			n, ok := v.Value().(int64)
+			if !ok || n < 1 {
+				err = fmt.Errorf("invalid document number")
+			}
+			doc = int(n)
+		}
+		if err != nil {
+			return op, doc, fmt.Errorf("operation %d: %s", no+1, err)
+		}
+	}
+	switch op.Op {
+	case OP_ADD, OP_REMOVE, OP_REPLACE, OP_TEST:
+	case OP_MOVE, OP_COPY:
+		if _, ok := m["from"]; !ok {
+			return op, doc, fmt.Errorf("operation %d: from required for %s", no+1, op.Op)
+		}
+	default:
+		return op, doc, fmt.Errorf("operation %d: invalid operation %q", no+1, op.Op)
+	}
+	if _, ok := m["path"]; !ok {
+		return op, doc, fmt.Errorf("operation %d: path required", no+1)
+	}
+	return op, doc, nil
+}
+
+func stringField(name string, node yaml.Node) (string, error) {
+	if s, ok := node.Value().(string); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("%s must be a string", name)
+}
+
+// ApplyStream applies patches to the documents of a stream. The
+// patch at an index is applied to the document with the same index.
+func ApplyStream(docs []yaml.Node, patches [][]PatchOperation) ([]yaml.Node, error) {
+	if len(patches) > len(docs) {
+		return nil, fmt.Errorf("patch for %d documents, but stream has %d documents", len(patches), len(docs))
+	}
+	conflicts := []Conflict{}
+	result := make([]yaml.Node, len(docs))
+	copy(result, docs)
+	for i, p := range patches {
+		doc, c := apply(docs[i], p)
+		if len(docs) > 1 {
+			for j := range c {
+				c[j].Document = i + 1
+			}
+		}
+		conflicts = append(conflicts, c...)
+		result[i] = doc
+	}
+	if len(conflicts) > 0 {
+		return nil, &PatchError{conflicts}
+	}
+	return result, nil
+}
+
+// Apply applies a patch to a document. If operations cannot be applied,
+// a PatchError describing all conflicts is returned.
+func Apply(doc yaml.Node, patch []PatchOperation) (yaml.Node, error) {
+	result, conflicts := apply(doc, patch)
+	if len(conflicts) > 0 {
+		return nil, &PatchError{conflicts}
+	}
+	return result, nil
+}
+
+func apply(doc yaml.Node, patch []PatchOperation) (yaml.Node, []Conflict) {
+	conflicts := []Conflict{}
+	for _, op := range patch {
+		result, err := applyOperation(doc, op)
+		if err != nil {
+			conflicts = append(conflicts, Conflict{Op: op.Op, Path: op.Path, Message: err.Error()})
+			continue
+		}
+		doc = result
+	}
+	return doc, conflicts
+}
+
+func applyOperation(doc yaml.Node, op PatchOperation) (yaml.Node, error) {
+	path, pointer := pathTokens(op.Path)
+	var value yaml.Node
+
+	switch op.Op {
+	case OP_ADD, OP_REPLACE, OP_TEST:
+		v, err := yaml.Sanitize("patch", op.Value)
+		if err != nil {
+			return nil, err
+		}
+		value = v
+	case OP_MOVE, OP_COPY:
+		from, fpointer := pathTokens(op.From)
+		v, err := get(doc, from, fpointer)
+		if err != nil {
+			return nil, fmt.Errorf("from: %s", err)
+		}
+		value = v
+		if op.Op == OP_MOVE {
+			if len(path) > len(from) && comparePointer(from, path[:len(from)]) == 0 {
+				return nil, fmt.Errorf("cannot move %s into itself", op.From)
+			}
+			doc, err = modify(doc, from, fpointer, OP_REMOVE, nil)
+			if err != nil {
+				return nil, fmt.Errorf("from: %s", err)
+			}
+		}
+	}
+
+	if op.HasOld || op.Op == OP_TEST {
+		expected := op.Old
+		if op.Op == OP_TEST {
+			expected = op.Value
+		}
+		var found interface{}
+		cur, err := get(doc, path, pointer)
+		if err != nil {
+			if op.Op == OP_TEST || expected != nil {
+				return nil, err
+			}
+		} else {
+			found, err = yaml.Normalize(cur)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !equalValues(expected, found) {
+			return nil, fmt.Errorf("expected value %s, but found %s", describeValue(expected), describeValue(found))
+		}
+	}
+	if op.Op == OP_TEST {
+		return doc, nil
+	}
+
+	action := op.Op
+	if action == OP_MOVE || action == OP_COPY {
+		action = OP_ADD
+	}
+	return modify(doc, path, pointer, action, value)
+}
+
+// pathTokens splits a path into its components. Paths starting with a
+// slash are JSON pointers (RFC 6901), otherwise dynaml references.
+func pathTokens(path string) ([]string, bool) {
+	if path == "" {
+		return []string{}, true
+	}
+	if !strings.HasPrefix(path, "/") {
+		return dynaml.PathComponents(path, false), false
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, true
+}
+
+func get(node yaml.Node, path []string, pointer bool) (yaml.Node, error) {
+	for i, t := range path {
+		switch v := node.Value().(type) {
+		case map[string]yaml.Node:
+			n, ok := v[t]
+			if !ok {
+				return nil, pathError(path, i, pointer, "not found")
+			}
+			node = n
+		case []yaml.Node:
+			index, err := listIndex(v, t, pointer, false)
+			if err != nil {
+				return nil, pathError(path, i, pointer, err.Error())
+			}
+			node = v[index]
+		default:
+			return nil, pathError(path, i-1, pointer, "no map or list")
+		}
+	}
+	return node, nil
+}
+
+// modify executes an add, remove or replace action for the node
+// addressed by a path and returns the modified document.
+func modify(node yaml.Node, path []string, pointer bool, action string, value yaml.Node) (yaml.Node, error) {
+	if len(path) == 0 {
+		if action == OP_REMOVE {
+			return nil, fmt.Errorf("document cannot be removed")
+		}
+		return value, nil
+	}
+	return modifyStep(node, path, 0, pointer, action, value)
+}
+
+func modifyStep(node yaml.Node, path []string, i int, pointer bool, action string, value yaml.Node) (yaml.Node, error) {
+	last := i == len(path)-1
+	t := path[i]
+	switch v := node.Value().(type) {
+	case map[string]yaml.Node:
+		n, ok := v[t]
+		if !ok && !(last && action == OP_ADD) {
+			return nil, pathError(path, i, pointer, "not found")
+		}
+		m := make(map[string]yaml.Node, len(v))
+		for k, e := range v {
+			m[k] = e
+		}
+		switch {
+		case !last:
+			sub, err := modifyStep(n, path, i+1, pointer, action, value)
+			if err != nil {
+				return nil, err
+			}
+			m[t] = sub
+		case action == OP_REMOVE:
+			delete(m, t)
+		default:
+			m[t] = value
+		}
+		return yaml.SubstituteNode(m, node), nil
+	case []yaml.Node:
+		index, err := listIndex(v, t, pointer, last && action == OP_ADD)
+		if err != nil {
+			return nil, pathError(path, i, pointer, err.Error())
+		}
+		l := make([]yaml.Node, 0, len(v)+1)
+		l = append(l, v[:index]...)
+		switch {
+		case !last:
+			sub, err := modifyStep(v[index], path, i+1, pointer, action, value)
+			if err != nil {
+				return nil, err
+			}
+			l = append(append(l, sub), v[index+1:]...)
+		case action == OP_REMOVE:
+			l = append(l, v[index+1:]...)
+		case action == OP_ADD:
+			l = append(append(l, value), v[index:]...)
+		default:
+			l = append(append(l, value), v[index+1:]...)
+		}
+		return yaml.SubstituteNode(l, node), nil
+	default:
+		return nil, pathError(path, i-1, pointer, "no map or list")
+	}
+}
+
+// listIndex determines the index of a list entry addressed by a
+// path component. JSON pointers use plain indices, dynaml references
+// use [<index>], <value> for the value of the field name or
+// <field>:<value>. For additions the index may address the end of
+// the list, which is also denoted by -. For dynaml references
+// a missing entry is appended.
+func listIndex(list []yaml.Node, t string, pointer bool, add bool) (int, error) {
+	max := len(list)
+	if add {
+		max++
+	}
+	if t == "-" {
+		if !add {
+			return 0, fmt.Errorf("end of list can only be used for additions")
+		}
+		return len(list), nil
+	}
+	if pointer || strings.HasPrefix(t, "[") {
+		s := t
+		if !pointer {
+			s = strings.TrimSuffix(strings.TrimPrefix(t, "["), "]")
+		}
+		index, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid list index")
+		}
+		if index < 0 && !pointer {
+			index += len(list)
+		}
+		if index < 0 || index >= max {
+			return 0, fmt.Errorf("list index out of range")
+		}
+		return index, nil
+	}
+
+	key := DefaultKey
+	if i := strings.Index(t, ":"); i > 0 {
+		key = t[:i]
+		t = t[i+1:]
+	}
+	for i, e := range list {
+		if name, ok := keyValue(e, key); ok && name == t {
+			return i, nil
+		}
+	}
+	if add {
+		return len(list), nil
+	}
+	return 0, fmt.Errorf("no list entry with %s %q", key, t)
+}
+
+// pathError describes an error for the node at a path index. The
+// path is omitted for the complete path of an operation.
+func pathError(path []string, i int, pointer bool, msg string) error {
+	if i == len(path)-1 {
+		return fmt.Errorf("%s", msg)
+	}
+	if i < 0 {
+		return fmt.Errorf("document: %s", msg)
+	}
+	return fmt.Errorf("%s: %s", join(path[:i+1], pointer), msg)
+}
+
+func join(path []string, pointer bool) string {
+	if pointer {
+		return Pointer(path)
+	}
+	return strings.Join(path, ".")
+}
+
+// equalValues compares normalized values, integer and
+// float values are equal if they describe the same number.
+func equalValues(a, b interface{}) bool {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, e := range av {
+			o, ok := bv[k]
+			if !ok || !equalValues(e, o) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i, e := range av {
+			if !equalValues(e, bv[i]) {
+				return false
+			}
+		}
+		return true
+	case int64:
+		switch bv := b.(type) {
+		case int64:
+			return av == bv
+		case float64:
+			return float64(av) == bv
+		}
+		return false
+	case float64:
+		switch bv := b.(type) {
+		case int64:
+			return av == float64(bv)
+		case float64:
+			return av == bv
+		}
+		return false
+	default:
+		return a == b
+	}
+}
+
+func describeValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -2,6 +2,7 @@ package dynaml
 
 import (
 	"fmt"
+	"math/big"
 	"net"
 )
 
@@ -18,7 +19,7 @@ func (e AdditionExpr) Evaluate(binding Binding, locally bool) (interface{}, Eval
 		return nil, info, false
 	}
 
-	bint, info, ok := ResolveIntegerExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
+	b, info, ok := ResolveExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
 	if !ok {
 		return nil, info, false
 	}
@@ -27,6 +28,15 @@ func (e AdditionExpr) Evaluate(binding Binding, locally bool) (interface{}, Eval
 		return e, info, true
 	}
 
+	if fa, fb, ok := floatOperands(a, b); ok {
+		return floatResult(fa+fb, info)
+	}
+
+	bint, ok := b.(int64)
+	if !ok {
+		return info.Error("integer operand required")
+	}
+
 	aint, ok := a.(int64)
 	if ok {
 		return aint + bint, info, true
@@ -40,7 +50,7 @@ func (e AdditionExpr) Evaluate(binding Binding, locally bool) (interface{}, Eval
 		}
 		return info.Error("string argument for PLUS must be an IP address")
 	}
-	return info.Error("first argument of PLUS must be IP address or integer")
+	return info.Error("first argument of PLUS must be IP address or number")
 }
 
 func (e AdditionExpr) String() string {
@@ -48,16 +58,5 @@ func (e AdditionExpr) String() string {
 }
 
 func IPAdd(ip net.IP, offset int64) net.IP {
-	for j := len(ip) - 1; j >= 0; j-- {
-		tmp := offset + int64(ip[j])
-		ip[j] = byte(tmp)
-		if tmp < 0 {
-			tmp = tmp - 256
-		}
-		offset = tmp / 256
-		if offset == 0 {
-			break
-		}
-	}
-	return ip
+	return IPAddBig(ip, big.NewInt(offset))
 }
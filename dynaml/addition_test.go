@@ -15,6 +15,35 @@ var _ = Describe("addition", func() {
 		Expect(expr).To(EvaluateAs(5, FakeBinding{}))
 	})
 
+	It("adds floats", func() {
+		expr := AdditionExpr{
+			FloatExpr{1.5},
+			FloatExpr{0.25},
+		}
+
+		Expect(expr).To(EvaluateAs(1.75, FakeBinding{}))
+	})
+
+	It("adds integers and floats", func() {
+		expr := AdditionExpr{
+			IntegerExpr{2},
+			FloatExpr{0.5},
+		}
+
+		Expect(expr).To(EvaluateAs(2.5, FakeBinding{}))
+	})
+
+	Context("when the float result overflows", func() {
+		It("fails", func() {
+			expr := AdditionExpr{
+				FloatExpr{1.7e308},
+				FloatExpr{1.7e308},
+			}
+
+			Expect(expr).To(FailToEvaluate(FakeBinding{}))
+		})
+	})
+
 	Context("when the left-hand side is not an integer", func() {
 		It("fails", func() {
 			expr := AdditionExpr{
@@ -87,6 +87,62 @@ var _ = Describe("calls", func() {
 				),
 			)
 		})
+
+		It("determines minimal and maximal IPv6", func() {
+			expr := CallExpr{
+				Function: ReferenceExpr{[]string{"max_ip"}},
+				Arguments: []Expression{
+					StringExpr{"2001:db8::1/112"},
+				},
+			}
+
+			Expect(expr).To(
+				EvaluateAs(
+					"2001:db8::ffff",
+					FakeBinding{},
+				),
+			)
+
+			expr.Function = ReferenceExpr{[]string{"min_ip"}}
+			Expect(expr).To(
+				EvaluateAs(
+					"2001:db8::",
+					FakeBinding{},
+				),
+			)
+		})
+
+		It("determines number of IPs for IPv6", func() {
+			expr := CallExpr{
+				Function: ReferenceExpr{[]string{"num_ip"}},
+				Arguments: []Expression{
+					StringExpr{"2001:db8::/96"},
+				},
+			}
+
+			Expect(expr).To(
+				EvaluateAs(
+					int64(1<<32),
+					FakeBinding{},
+				),
+			)
+		})
+
+		It("determines number of IPs exceeding the integer range", func() {
+			expr := CallExpr{
+				Function: ReferenceExpr{[]string{"num_ip"}},
+				Arguments: []Expression{
+					StringExpr{"2001:db8::/64"},
+				},
+			}
+
+			Expect(expr).To(
+				EvaluateAs(
+					float64(1<<64),
+					FakeBinding{},
+				),
+			)
+		})
 	})
 
 	Describe("join(\", \"...)", func() {
@@ -285,5 +341,23 @@ var _ = Describe("calls", func() {
 				)
 			})
 		})
+
+		Context("when the network is IPv6", func() {
+			It("returns a set of ips from the given network's subnets", func() {
+				subnets := parseYAML(`
+- static:
+    - 2001:db8::fffe - 2001:db8::1:ffff
+`)
+
+				binding := newNetworkFakeBinding(subnets, 2)
+
+				Expect(expr).To(
+					EvaluateAs(
+						[]yaml.Node{NewNode("2001:db8::fffe", nil), NewNode("2001:db8::1:2", nil)},
+						binding,
+					),
+				)
+			})
+		})
 	})
 })
@@ -42,11 +42,24 @@ func (e ComparisonExpr) Evaluate(binding Binding, locally bool) (interface{}, Ev
 		result, infor, ok = compareEquals(a, b)
 		result = !result
 	case "<=", "<", ">", ">=":
+		if fa, fb, ok := floatOperands(a, b); ok {
+			switch e.Op {
+			case "<=":
+				result = fa <= fb
+			case "<":
+				result = fa < fb
+			case ">":
+				result = fa > fb
+			case ">=":
+				result = fa >= fb
+			}
+			break
+		}
 		switch va := a.(type) {
 		case int64:
 			vb, ok := b.(int64)
 			if !ok {
-				return infor.Error("comparision %s only for integers or strings", e.Op)
+				return infor.Error("comparision %s only for numbers or strings", e.Op)
 			}
 			switch e.Op {
 			case "<=":
@@ -62,7 +75,7 @@ func (e ComparisonExpr) Evaluate(binding Binding, locally bool) (interface{}, Ev
 		case string:
 			vb, ok := b.(string)
 			if !ok {
-				return infor.Error("comparision %s only for strings or integers", e.Op)
+				return infor.Error("comparision %s only for strings or numbers", e.Op)
 			}
 			switch e.Op {
 			case "<=":
@@ -113,6 +126,8 @@ func compareEquals(a, b interface{}) (bool, EvaluationInfo, bool) {
 			vb = v
 		case int64:
 			vb = strconv.FormatInt(v, 10)
+		case float64:
+			vb = FloatString(v)
 		case LambdaValue:
 			vb = v.String()
 		case bool:
@@ -139,6 +154,8 @@ func compareEquals(a, b interface{}) (bool, EvaluationInfo, bool) {
 			}
 		case int64:
 			vb = v
+		case float64:
+			return float64(va) == v, info, true
 		case bool:
 			if v {
 				vb = 1
@@ -155,6 +172,30 @@ func compareEquals(a, b interface{}) (bool, EvaluationInfo, bool) {
 		debug.Debug("compare failed: %v != %v\n", va, vb)
 		return false, info, true
 
+	case float64:
+		var vb float64
+		var err error
+		switch v := b.(type) {
+		case string:
+			vb, err = strconv.ParseFloat(v, 64)
+			if err != nil {
+				debug.Debug("compare failed: no float '%v'\n", v)
+				return false, info, true
+			}
+		case int64:
+			vb = float64(v)
+		case float64:
+			vb = v
+		default:
+			debug.Debug("compare failed: no float '%T'\n", b)
+			return false, info, true
+		}
+		if va == vb {
+			return true, info, true
+		}
+		debug.Debug("compare failed: %v != %v\n", va, vb)
+		return false, info, true
+
 	case bool:
 		var vb bool
 		var err error
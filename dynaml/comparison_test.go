@@ -33,6 +33,28 @@ var _ = Describe("comparison operators", func() {
 		compareIt("!=", []bool{true, false, true})
 	})
 
+	Context("with floats", func() {
+		It("compares integers and floats", func() {
+			expr := ComparisonExpr{
+				IntegerExpr{5},
+				"<",
+				FloatExpr{5.5},
+			}
+
+			Expect(expr).To(EvaluateAs(true, FakeBinding{}))
+		})
+
+		It("compares equal numbers", func() {
+			expr := ComparisonExpr{
+				FloatExpr{6.0},
+				"==",
+				IntegerExpr{6},
+			}
+
+			Expect(expr).To(EvaluateAs(true, FakeBinding{}))
+		})
+	})
+
 	Context("when one side fails", func() {
 		It("fails for left side failing", func() {
 			expr := ComparisonExpr{
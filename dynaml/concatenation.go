@@ -89,6 +89,8 @@ func concatenateString(a interface{}, b interface{}) (string, bool) {
 		aString = v
 	case int64:
 		aString = strconv.FormatInt(v, 10)
+	case float64:
+		aString = FloatString(v)
 	case bool:
 		aString = strconv.FormatBool(v)
 	default:
@@ -100,6 +102,8 @@ func concatenateString(a interface{}, b interface{}) (string, bool) {
 		return aString + v, true
 	case int64:
 		return aString + strconv.FormatInt(v, 10), true
+	case float64:
+		return aString + FloatString(v), true
 	case bool:
 		return aString + strconv.FormatBool(v), true
 	case LambdaValue:
@@ -49,6 +49,8 @@ func toBool(v interface{}) bool {
 		return len(eff) > 0
 	case int64:
 		return eff != 0
+	case float64:
+		return eff != 0
 	case []yaml.Node:
 		return len(eff) != 0
 	case map[string]yaml.Node:
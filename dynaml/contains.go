@@ -33,6 +33,8 @@ func func_contains(arguments []interface{}, binding Binding) (interface{}, Evalu
 			return strings.Contains(val, elem), info, true
 		case int64:
 			return strings.Contains(val, strconv.FormatInt(elem, 10)), info, true
+		case float64:
+			return strings.Contains(val, FloatString(elem)), info, true
 		case bool:
 			return strings.Contains(val, strconv.FormatBool(elem)), info, true
 		default:
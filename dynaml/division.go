@@ -18,7 +18,7 @@ func (e DivisionExpr) Evaluate(binding Binding, locally bool) (interface{}, Eval
 		return nil, info, false
 	}
 
-	bint, info, ok := ResolveIntegerExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
+	b, info, ok := ResolveExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
 	if !ok {
 		return nil, info, false
 	}
@@ -27,6 +27,18 @@ func (e DivisionExpr) Evaluate(binding Binding, locally bool) (interface{}, Eval
 		return e, info, true
 	}
 
+	if fa, fb, ok := floatOperands(a, b); ok {
+		if fb == 0 {
+			return info.Error("division by zero")
+		}
+		return floatResult(fa/fb, info)
+	}
+
+	bint, ok := b.(int64)
+	if !ok {
+		return info.Error("integer operand required")
+	}
+
 	if bint == 0 {
 		return info.Error("division by zero")
 	}
@@ -55,12 +67,12 @@ func (e DivisionExpr) Evaluate(binding Binding, locally bool) (interface{}, Eval
 		if round {
 			ones++
 		}
-		if ones > 32 {
+		if ones > bits {
 			return info.Error("divisor too large for CIDR network size")
 		}
 		return (&net.IPNet{ip, net.CIDRMask(ones, bits)}).String(), info, true
 	}
-	return info.Error("CIDR or int argument required as first argument for division")
+	return info.Error("CIDR or number argument required as first argument for division")
 }
 
 func (e DivisionExpr) String() string {
@@ -15,6 +15,24 @@ var _ = Describe("division", func() {
 		Expect(expr).To(EvaluateAs(2, FakeBinding{}))
 	})
 
+	It("keeps integer division for integers", func() {
+		expr := DivisionExpr{
+			IntegerExpr{7},
+			IntegerExpr{2},
+		}
+
+		Expect(expr).To(EvaluateAs(3, FakeBinding{}))
+	})
+
+	It("divides floats", func() {
+		expr := DivisionExpr{
+			IntegerExpr{7},
+			FloatExpr{2},
+		}
+
+		Expect(expr).To(EvaluateAs(3.5, FakeBinding{}))
+	})
+
 	Context("when the left-hand side is not an integer", func() {
 		It("fails", func() {
 			expr := DivisionExpr{
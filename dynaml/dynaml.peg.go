@@ -66,6 +66,7 @@ const (
 	ruleRange
 	ruleStartRange
 	ruleRangeOp
+	ruleFloat
 	ruleInteger
 	ruleString
 	ruleBoolean
@@ -176,6 +177,7 @@ var rul3s = [...]string{
 	"Range",
 	"StartRange",
 	"RangeOp",
+	"Float",
 	"Integer",
 	"String",
 	"Boolean",
@@ -544,7 +546,7 @@ func (t *tokens32) Expand(index int) tokenTree {
 type DynamlGrammar struct {
 	Buffer string
 	buffer []rune
-	rules  [103]func() bool
+	rules  [104]func() bool
 	Parse  func(rule ...int) error
 	Reset  func()
 	Pretty bool
@@ -1831,7 +1833,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position107, tokenIndex107, depth107
 			return false
 		},
-		/* 30 Level0 <- <(IP / String / Integer / Boolean / Undefined / Nil / Symbol / Not / Substitution / Merge / Auto / Lambda / Chained)> */
+		/* 30 Level0 <- <(IP / String / Float / Integer / Boolean / Undefined / Nil / Symbol / Not / Substitution / Merge / Auto / Lambda / Chained)> */
 		func() bool {
 			position109, tokenIndex109, depth109 := position, tokenIndex, depth
 			{
@@ -1850,6 +1852,12 @@ func (p *DynamlGrammar) Init() {
 					}
 					goto l111
 				l113:
+					position, tokenIndex, depth = position111, tokenIndex111, depth111
+					if !_rules[ruleFloat]() {
+						goto l427
+					}
+					goto l111
+				l427:
 					position, tokenIndex, depth = position111, tokenIndex111, depth111
 					if !_rules[ruleInteger]() {
 						goto l114
@@ -2621,7 +2629,136 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position207, tokenIndex207, depth207
 			return false
 		},
-		/* 52 Integer <- <('-'? [0-9] ([0-9] / '_')*)> */
+		/* 52 Float <- <('-'? [0-9] ([0-9] / '_')* '.' [0-9]+ (('e' / 'E') ('-' / '+')? [0-9]+)?)> */
+		func() bool {
+			position428, tokenIndex428, depth428 := position, tokenIndex, depth
+			{
+				position429 := position
+				depth++
+				{
+					position430, tokenIndex430, depth430 := position, tokenIndex, depth
+					if buffer[position] != rune('-') {
+						goto l430
+					}
+					position++
+					goto l431
+				l430:
+					position, tokenIndex, depth = position430, tokenIndex430, depth430
+				}
+			l431:
+				if c := buffer[position]; c < rune('0') || c > rune('9') {
+					goto l428
+				}
+				position++
+			l432:
+				{
+					position433, tokenIndex433, depth433 := position, tokenIndex, depth
+					{
+						position434, tokenIndex434, depth434 := position, tokenIndex, depth
+						if c := buffer[position]; c < rune('0') || c > rune('9') {
+							goto l435
+						}
+						position++
+						goto l434
+					l435:
+						position, tokenIndex, depth = position434, tokenIndex434, depth434
+						if buffer[position] != rune('_') {
+							goto l433
+						}
+						position++
+					}
+				l434:
+					goto l432
+				l433:
+					position, tokenIndex, depth = position433, tokenIndex433, depth433
+				}
+				if buffer[position] != rune('.') {
+					goto l428
+				}
+				position++
+				if c := buffer[position]; c < rune('0') || c > rune('9') {
+					goto l428
+				}
+				position++
+			l436:
+				{
+					position437, tokenIndex437, depth437 := position, tokenIndex, depth
+					if c := buffer[position]; c < rune('0') || c > rune('9') {
+						goto l437
+					}
+					position++
+					goto l436
+				l437:
+					position, tokenIndex, depth = position437, tokenIndex437, depth437
+				}
+				{
+					position438, tokenIndex438, depth438 := position, tokenIndex, depth
+					{
+						position440, tokenIndex440, depth440 := position, tokenIndex, depth
+						if buffer[position] != rune('e') {
+							goto l441
+						}
+						position++
+						goto l440
+					l441:
+						position, tokenIndex, depth = position440, tokenIndex440, depth440
+						if buffer[position] != rune('E') {
+							goto l438
+						}
+						position++
+					}
+				l440:
+					{
+						position442, tokenIndex442, depth442 := position, tokenIndex, depth
+						{
+							position444, tokenIndex444, depth444 := position, tokenIndex, depth
+							if buffer[position] != rune('-') {
+								goto l445
+							}
+							position++
+							goto l444
+						l445:
+							position, tokenIndex, depth = position444, tokenIndex444, depth444
+							if buffer[position] != rune('+') {
+								goto l442
+							}
+							position++
+						}
+					l444:
+						goto l443
+					l442:
+						position, tokenIndex, depth = position442, tokenIndex442, depth442
+					}
+				l443:
+					if c := buffer[position]; c < rune('0') || c > rune('9') {
+						goto l438
+					}
+					position++
+				l446:
+					{
+						position447, tokenIndex447, depth447 := position, tokenIndex, depth
+						if c := buffer[position]; c < rune('0') || c > rune('9') {
+							goto l447
+						}
+						position++
+						goto l446
+					l447:
+						position, tokenIndex, depth = position447, tokenIndex447, depth447
+					}
+					goto l439
+				l438:
+					position, tokenIndex, depth = position438, tokenIndex438, depth438
+				}
+			l439:
+				depth--
+				add(ruleFloat, position429)
+			}
+			return true
+		l428:
+			position, tokenIndex, depth = position428, tokenIndex428, depth428
+			return false
+		},
+		/* 53 Integer <- <('-'? [0-9] ([0-9] / '_')*)> */
 		func() bool {
 			position209, tokenIndex209, depth209 := position, tokenIndex, depth
 			{
@@ -2672,7 +2809,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position209, tokenIndex209, depth209
 			return false
 		},
-		/* 53 String <- <('"' (('\\' '"') / (!'"' .))* '"')> */
+		/* 54 String <- <('"' (('\\' '"') / (!'"' .))* '"')> */
 		func() bool {
 			position217, tokenIndex217, depth217 := position, tokenIndex, depth
 			{
@@ -2729,7 +2866,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position217, tokenIndex217, depth217
 			return false
 		},
-		/* 54 Boolean <- <(('t' 'r' 'u' 'e') / ('f' 'a' 'l' 's' 'e'))> */
+		/* 55 Boolean <- <(('t' 'r' 'u' 'e') / ('f' 'a' 'l' 's' 'e'))> */
 		func() bool {
 			position224, tokenIndex224, depth224 := position, tokenIndex, depth
 			{
@@ -2786,7 +2923,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position224, tokenIndex224, depth224
 			return false
 		},
-		/* 55 Nil <- <(('n' 'i' 'l') / '~')> */
+		/* 56 Nil <- <(('n' 'i' 'l') / '~')> */
 		func() bool {
 			position228, tokenIndex228, depth228 := position, tokenIndex, depth
 			{
@@ -2823,7 +2960,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position228, tokenIndex228, depth228
 			return false
 		},
-		/* 56 Undefined <- <('~' '~')> */
+		/* 57 Undefined <- <('~' '~')> */
 		func() bool {
 			position232, tokenIndex232, depth232 := position, tokenIndex, depth
 			{
@@ -2845,7 +2982,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position232, tokenIndex232, depth232
 			return false
 		},
-		/* 57 Symbol <- <('$' Name)> */
+		/* 58 Symbol <- <('$' Name)> */
 		func() bool {
 			position234, tokenIndex234, depth234 := position, tokenIndex, depth
 			{
@@ -2866,7 +3003,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position234, tokenIndex234, depth234
 			return false
 		},
-		/* 58 List <- <(StartList ExpressionList? ']')> */
+		/* 59 List <- <(StartList ExpressionList? ']')> */
 		func() bool {
 			position236, tokenIndex236, depth236 := position, tokenIndex, depth
 			{
@@ -2897,7 +3034,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position236, tokenIndex236, depth236
 			return false
 		},
-		/* 59 StartList <- <('[' ws)> */
+		/* 60 StartList <- <('[' ws)> */
 		func() bool {
 			position240, tokenIndex240, depth240 := position, tokenIndex, depth
 			{
@@ -2918,7 +3055,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position240, tokenIndex240, depth240
 			return false
 		},
-		/* 60 Map <- <(CreateMap ws Assignments? '}')> */
+		/* 61 Map <- <(CreateMap ws Assignments? '}')> */
 		func() bool {
 			position242, tokenIndex242, depth242 := position, tokenIndex, depth
 			{
@@ -2952,7 +3089,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position242, tokenIndex242, depth242
 			return false
 		},
-		/* 61 CreateMap <- <'{'> */
+		/* 62 CreateMap <- <'{'> */
 		func() bool {
 			position246, tokenIndex246, depth246 := position, tokenIndex, depth
 			{
@@ -2970,7 +3107,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position246, tokenIndex246, depth246
 			return false
 		},
-		/* 62 Assignments <- <(Assignment (',' Assignment)*)> */
+		/* 63 Assignments <- <(Assignment (',' Assignment)*)> */
 		func() bool {
 			position248, tokenIndex248, depth248 := position, tokenIndex, depth
 			{
@@ -3001,7 +3138,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position248, tokenIndex248, depth248
 			return false
 		},
-		/* 63 Assignment <- <(Expression '=' Expression)> */
+		/* 64 Assignment <- <(Expression '=' Expression)> */
 		func() bool {
 			position252, tokenIndex252, depth252 := position, tokenIndex, depth
 			{
@@ -3025,7 +3162,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position252, tokenIndex252, depth252
 			return false
 		},
-		/* 64 Merge <- <(RefMerge / SimpleMerge)> */
+		/* 65 Merge <- <(RefMerge / SimpleMerge)> */
 		func() bool {
 			position254, tokenIndex254, depth254 := position, tokenIndex, depth
 			{
@@ -3052,7 +3189,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position254, tokenIndex254, depth254
 			return false
 		},
-		/* 65 RefMerge <- <('m' 'e' 'r' 'g' 'e' !(req_ws Required) (req_ws (Replace / On))? req_ws Reference)> */
+		/* 66 RefMerge <- <('m' 'e' 'r' 'g' 'e' !(req_ws Required) (req_ws (Replace / On))? req_ws Reference)> */
 		func() bool {
 			position258, tokenIndex258, depth258 := position, tokenIndex, depth
 			{
@@ -3127,7 +3264,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position258, tokenIndex258, depth258
 			return false
 		},
-		/* 66 SimpleMerge <- <('m' 'e' 'r' 'g' 'e' !'(' (req_ws (Replace / Required / On))?)> */
+		/* 67 SimpleMerge <- <('m' 'e' 'r' 'g' 'e' !'(' (req_ws (Replace / Required / On))?)> */
 		func() bool {
 			position265, tokenIndex265, depth265 := position, tokenIndex, depth
 			{
@@ -3200,7 +3337,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position265, tokenIndex265, depth265
 			return false
 		},
-		/* 67 Replace <- <('r' 'e' 'p' 'l' 'a' 'c' 'e')> */
+		/* 68 Replace <- <('r' 'e' 'p' 'l' 'a' 'c' 'e')> */
 		func() bool {
 			position273, tokenIndex273, depth273 := position, tokenIndex, depth
 			{
@@ -3242,7 +3379,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position273, tokenIndex273, depth273
 			return false
 		},
-		/* 68 Required <- <('r' 'e' 'q' 'u' 'i' 'r' 'e' 'd')> */
+		/* 69 Required <- <('r' 'e' 'q' 'u' 'i' 'r' 'e' 'd')> */
 		func() bool {
 			position275, tokenIndex275, depth275 := position, tokenIndex, depth
 			{
@@ -3288,7 +3425,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position275, tokenIndex275, depth275
 			return false
 		},
-		/* 69 On <- <('o' 'n' req_ws Name)> */
+		/* 70 On <- <('o' 'n' req_ws Name)> */
 		func() bool {
 			position277, tokenIndex277, depth277 := position, tokenIndex, depth
 			{
@@ -3316,7 +3453,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position277, tokenIndex277, depth277
 			return false
 		},
-		/* 70 Auto <- <('a' 'u' 't' 'o')> */
+		/* 71 Auto <- <('a' 'u' 't' 'o')> */
 		func() bool {
 			position279, tokenIndex279, depth279 := position, tokenIndex, depth
 			{
@@ -3346,7 +3483,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position279, tokenIndex279, depth279
 			return false
 		},
-		/* 71 Default <- <Action1> */
+		/* 72 Default <- <Action1> */
 		func() bool {
 			position281, tokenIndex281, depth281 := position, tokenIndex, depth
 			{
@@ -3363,7 +3500,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position281, tokenIndex281, depth281
 			return false
 		},
-		/* 72 Sync <- <('s' 'y' 'n' 'c' '[' Level7 ((((LambdaExpr LambdaExt) / (LambdaOrExpr LambdaOrExpr)) (('|' Expression) / Default)) / (LambdaOrExpr Default Default)) ']')> */
+		/* 73 Sync <- <('s' 'y' 'n' 'c' '[' Level7 ((((LambdaExpr LambdaExt) / (LambdaOrExpr LambdaOrExpr)) (('|' Expression) / Default)) / (LambdaOrExpr Default Default)) ']')> */
 		func() bool {
 			position283, tokenIndex283, depth283 := position, tokenIndex, depth
 			{
@@ -3456,7 +3593,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position283, tokenIndex283, depth283
 			return false
 		},
-		/* 73 LambdaExt <- <(',' Expression)> */
+		/* 74 LambdaExt <- <(',' Expression)> */
 		func() bool {
 			position291, tokenIndex291, depth291 := position, tokenIndex, depth
 			{
@@ -3477,7 +3614,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position291, tokenIndex291, depth291
 			return false
 		},
-		/* 74 LambdaOrExpr <- <(LambdaExpr / ('|' Expression))> */
+		/* 75 LambdaOrExpr <- <(LambdaExpr / ('|' Expression))> */
 		func() bool {
 			position293, tokenIndex293, depth293 := position, tokenIndex, depth
 			{
@@ -3508,7 +3645,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position293, tokenIndex293, depth293
 			return false
 		},
-		/* 75 Catch <- <('c' 'a' 't' 'c' 'h' '[' Level7 LambdaOrExpr ']')> */
+		/* 76 Catch <- <('c' 'a' 't' 'c' 'h' '[' Level7 LambdaOrExpr ']')> */
 		func() bool {
 			position297, tokenIndex297, depth297 := position, tokenIndex, depth
 			{
@@ -3556,7 +3693,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position297, tokenIndex297, depth297
 			return false
 		},
-		/* 76 MapMapping <- <('m' 'a' 'p' '{' Level7 LambdaOrExpr '}')> */
+		/* 77 MapMapping <- <('m' 'a' 'p' '{' Level7 LambdaOrExpr '}')> */
 		func() bool {
 			position299, tokenIndex299, depth299 := position, tokenIndex, depth
 			{
@@ -3596,7 +3733,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position299, tokenIndex299, depth299
 			return false
 		},
-		/* 77 Mapping <- <('m' 'a' 'p' '[' Level7 LambdaOrExpr ']')> */
+		/* 78 Mapping <- <('m' 'a' 'p' '[' Level7 LambdaOrExpr ']')> */
 		func() bool {
 			position301, tokenIndex301, depth301 := position, tokenIndex, depth
 			{
@@ -3636,7 +3773,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position301, tokenIndex301, depth301
 			return false
 		},
-		/* 78 MapSelection <- <('s' 'e' 'l' 'e' 'c' 't' '{' Level7 LambdaOrExpr '}')> */
+		/* 79 MapSelection <- <('s' 'e' 'l' 'e' 'c' 't' '{' Level7 LambdaOrExpr '}')> */
 		func() bool {
 			position303, tokenIndex303, depth303 := position, tokenIndex, depth
 			{
@@ -3688,7 +3825,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position303, tokenIndex303, depth303
 			return false
 		},
-		/* 79 Selection <- <('s' 'e' 'l' 'e' 'c' 't' '[' Level7 LambdaOrExpr ']')> */
+		/* 80 Selection <- <('s' 'e' 'l' 'e' 'c' 't' '[' Level7 LambdaOrExpr ']')> */
 		func() bool {
 			position305, tokenIndex305, depth305 := position, tokenIndex, depth
 			{
@@ -3740,7 +3877,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position305, tokenIndex305, depth305
 			return false
 		},
-		/* 80 Sum <- <('s' 'u' 'm' '[' Level7 '|' Level7 LambdaOrExpr ']')> */
+		/* 81 Sum <- <('s' 'u' 'm' '[' Level7 '|' Level7 LambdaOrExpr ']')> */
 		func() bool {
 			position307, tokenIndex307, depth307 := position, tokenIndex, depth
 			{
@@ -3787,7 +3924,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position307, tokenIndex307, depth307
 			return false
 		},
-		/* 81 Lambda <- <('l' 'a' 'm' 'b' 'd' 'a' (LambdaRef / LambdaExpr))> */
+		/* 82 Lambda <- <('l' 'a' 'm' 'b' 'd' 'a' (LambdaRef / LambdaExpr))> */
 		func() bool {
 			position309, tokenIndex309, depth309 := position, tokenIndex, depth
 			{
@@ -3838,7 +3975,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position309, tokenIndex309, depth309
 			return false
 		},
-		/* 82 LambdaRef <- <(req_ws Expression)> */
+		/* 83 LambdaRef <- <(req_ws Expression)> */
 		func() bool {
 			position313, tokenIndex313, depth313 := position, tokenIndex, depth
 			{
@@ -3858,7 +3995,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position313, tokenIndex313, depth313
 			return false
 		},
-		/* 83 LambdaExpr <- <(ws Params ws ('-' '>') Expression)> */
+		/* 84 LambdaExpr <- <(ws Params ws ('-' '>') Expression)> */
 		func() bool {
 			position315, tokenIndex315, depth315 := position, tokenIndex, depth
 			{
@@ -3892,7 +4029,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position315, tokenIndex315, depth315
 			return false
 		},
-		/* 84 Params <- <('|' StartParams ws Names? '|')> */
+		/* 85 Params <- <('|' StartParams ws Names? '|')> */
 		func() bool {
 			position317, tokenIndex317, depth317 := position, tokenIndex, depth
 			{
@@ -3930,7 +4067,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position317, tokenIndex317, depth317
 			return false
 		},
-		/* 85 StartParams <- <Action2> */
+		/* 86 StartParams <- <Action2> */
 		func() bool {
 			position321, tokenIndex321, depth321 := position, tokenIndex, depth
 			{
@@ -3947,7 +4084,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position321, tokenIndex321, depth321
 			return false
 		},
-		/* 86 Names <- <(NextName (',' NextName)* DefaultValue? (',' NextName DefaultValue)* VarParams?)> */
+		/* 87 Names <- <(NextName (',' NextName)* DefaultValue? (',' NextName DefaultValue)* VarParams?)> */
 		func() bool {
 			position323, tokenIndex323, depth323 := position, tokenIndex, depth
 			{
@@ -4015,7 +4152,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position323, tokenIndex323, depth323
 			return false
 		},
-		/* 87 NextName <- <(ws Name ws)> */
+		/* 88 NextName <- <(ws Name ws)> */
 		func() bool {
 			position333, tokenIndex333, depth333 := position, tokenIndex, depth
 			{
@@ -4038,7 +4175,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position333, tokenIndex333, depth333
 			return false
 		},
-		/* 88 Name <- <([a-z] / [A-Z] / [0-9] / '_')+> */
+		/* 89 Name <- <([a-z] / [A-Z] / [0-9] / '_')+> */
 		func() bool {
 			position335, tokenIndex335, depth335 := position, tokenIndex, depth
 			{
@@ -4117,7 +4254,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position335, tokenIndex335, depth335
 			return false
 		},
-		/* 89 DefaultValue <- <('=' Expression)> */
+		/* 90 DefaultValue <- <('=' Expression)> */
 		func() bool {
 			position347, tokenIndex347, depth347 := position, tokenIndex, depth
 			{
@@ -4138,7 +4275,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position347, tokenIndex347, depth347
 			return false
 		},
-		/* 90 VarParams <- <('.' '.' '.' ws)> */
+		/* 91 VarParams <- <('.' '.' '.' ws)> */
 		func() bool {
 			position349, tokenIndex349, depth349 := position, tokenIndex, depth
 			{
@@ -4167,7 +4304,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position349, tokenIndex349, depth349
 			return false
 		},
-		/* 91 Reference <- <('.'? Key FollowUpRef)> */
+		/* 92 Reference <- <('.'? Key FollowUpRef)> */
 		func() bool {
 			position351, tokenIndex351, depth351 := position, tokenIndex, depth
 			{
@@ -4198,7 +4335,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position351, tokenIndex351, depth351
 			return false
 		},
-		/* 92 FollowUpRef <- <PathComponent*> */
+		/* 93 FollowUpRef <- <PathComponent*> */
 		func() bool {
 			{
 				position356 := position
@@ -4218,7 +4355,7 @@ func (p *DynamlGrammar) Init() {
 			}
 			return true
 		},
-		/* 93 PathComponent <- <(('.' Key) / ('.'? Index))> */
+		/* 94 PathComponent <- <(('.' Key) / ('.'? Index))> */
 		func() bool {
 			position359, tokenIndex359, depth359 := position, tokenIndex, depth
 			{
@@ -4260,7 +4397,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position359, tokenIndex359, depth359
 			return false
 		},
-		/* 94 Key <- <(([a-z] / [A-Z] / [0-9] / '_') ([a-z] / [A-Z] / [0-9] / '_' / '-')* (':' ([a-z] / [A-Z] / [0-9] / '_') ([a-z] / [A-Z] / [0-9] / '_' / '-')*)?)> */
+		/* 95 Key <- <(([a-z] / [A-Z] / [0-9] / '_') ([a-z] / [A-Z] / [0-9] / '_' / '-')* (':' ([a-z] / [A-Z] / [0-9] / '_') ([a-z] / [A-Z] / [0-9] / '_' / '-')*)?)> */
 		func() bool {
 			position365, tokenIndex365, depth365 := position, tokenIndex, depth
 			{
@@ -4429,7 +4566,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position365, tokenIndex365, depth365
 			return false
 		},
-		/* 95 Index <- <('[' '-'? [0-9]+ ']')> */
+		/* 96 Index <- <('[' '-'? [0-9]+ ']')> */
 		func() bool {
 			position391, tokenIndex391, depth391 := position, tokenIndex, depth
 			{
@@ -4477,7 +4614,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position391, tokenIndex391, depth391
 			return false
 		},
-		/* 96 IP <- <([0-9]+ '.' [0-9]+ '.' [0-9]+ '.' [0-9]+)> */
+		/* 97 IP <- <([0-9]+ '.' [0-9]+ '.' [0-9]+ '.' [0-9]+)> */
 		func() bool {
 			position397, tokenIndex397, depth397 := position, tokenIndex, depth
 			{
@@ -4563,7 +4700,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position397, tokenIndex397, depth397
 			return false
 		},
-		/* 97 ws <- <(' ' / '\t' / '\n' / '\r')*> */
+		/* 98 ws <- <(' ' / '\t' / '\n' / '\r')*> */
 		func() bool {
 			{
 				position408 := position
@@ -4609,7 +4746,7 @@ func (p *DynamlGrammar) Init() {
 			}
 			return true
 		},
-		/* 98 req_ws <- <(' ' / '\t' / '\n' / '\r')+> */
+		/* 99 req_ws <- <(' ' / '\t' / '\n' / '\r')+> */
 		func() bool {
 			position415, tokenIndex415, depth415 := position, tokenIndex, depth
 			{
@@ -4688,21 +4825,21 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position415, tokenIndex415, depth415
 			return false
 		},
-		/* 100 Action0 <- <{}> */
+		/* 101 Action0 <- <{}> */
 		func() bool {
 			{
 				add(ruleAction0, position)
 			}
 			return true
 		},
-		/* 101 Action1 <- <{}> */
+		/* 102 Action1 <- <{}> */
 		func() bool {
 			{
 				add(ruleAction1, position)
 			}
 			return true
 		},
-		/* 102 Action2 <- <{}> */
+		/* 103 Action2 <- <{}> */
 		func() bool {
 			{
 				add(ruleAction2, position)
@@ -48,6 +48,9 @@ func func_exec(cached bool, arguments []interface{}, binding Binding) (interface
 			args = append(args, v)
 		}
 	}
+	if err := policy(binding).CheckCommand(args[0]); err != nil {
+		return info.Error("exec: %s", err)
+	}
 	result, err := cachedExecute(cached, nil, args)
 	if err != nil {
 		return info.Error("execution '%s' failed", args[0])
@@ -87,6 +90,8 @@ func getArg(key interface{}, value interface{}, yaml bool) (string, bool, bool)
 		return v, true, true
 	case int64:
 		return strconv.FormatInt(v, 10), false, true
+	case float64:
+		return FloatString(v), false, true
 	case bool:
 		return strconv.FormatBool(v), false, true
 	default:
@@ -1,6 +1,7 @@
 package dynaml
 
 import (
+	"github.com/mandelsoft/spiff/vfs"
 	"github.com/mandelsoft/spiff/yaml"
 )
 
@@ -18,6 +19,8 @@ type State interface {
 	GetTempName(data []byte) (string, error)
 	GetFileContent(file string, cached bool) ([]byte, error)
 	GetEncryptionKey() string
+	GetFileSystem() vfs.FileSystem
+	GetPolicy() *Policy
 }
 
 type Binding interface {
@@ -1,10 +1,23 @@
 package dynaml
 
 import (
+	"github.com/mandelsoft/spiff/vfs"
 	"github.com/mandelsoft/spiff/yaml"
-	"io/ioutil"
 )
 
+// fileSystem returns the file system to use for file operations
+// of a binding.
+func fileSystem(binding Binding) vfs.FileSystem {
+	if binding != nil {
+		if state := binding.GetState(); state != nil {
+			if fs := state.GetFileSystem(); fs != nil {
+				return fs
+			}
+		}
+	}
+	return vfs.OSFileSystem
+}
+
 func func_listFiles(directory bool, arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
 	info := DefaultInfo()
 
@@ -21,11 +34,15 @@ func func_listFiles(directory bool, arguments []interface{}, binding Binding) (i
 		return info.Error("list: argument is empty string")
 	}
 
-	if !checkExistence(name, true) {
+	if err := policy(binding).CheckFile(fileSystem(binding), name); err != nil {
+		return info.Error("list: %s", err)
+	}
+
+	if !checkExistence(binding, name, true) {
 		return info.Error("list: %q is no directory or does not exist", name)
 	}
 
-	files, err := ioutil.ReadDir(name)
+	files, err := fileSystem(binding).ReadDir(name)
 	if err != nil {
 		return info.Error("list: %q:  error reading directory", name, err)
 	}
@@ -0,0 +1,78 @@
+package dynaml
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+type FloatExpr struct {
+	Value float64
+}
+
+func (e FloatExpr) Evaluate(binding Binding, locally bool) (interface{}, EvaluationInfo, bool) {
+	return floatResult(e.Value, DefaultInfo())
+}
+
+func (e FloatExpr) String() string {
+	return FloatLiteral(e.Value)
+}
+
+// FloatLiteral formats a float value such that it is parsed
+// again as float literal by the dynaml parser.
+func FloatLiteral(v float64) string {
+	s := strconv.FormatFloat(v, 'g', -1, 64)
+	if strings.ContainsAny(s, ".NI") {
+		return s
+	}
+	if i := strings.Index(s, "e"); i >= 0 {
+		return s[:i] + ".0" + s[i:]
+	}
+	return s + ".0"
+}
+
+// FloatString formats a float value for string usage, for example
+// in string concatenations.
+func FloatString(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// floatOperands provides the float values of two numeric operands
+// if at least one of them is a float value. Pure integer operations
+// are left to the caller to preserve the integer semantics.
+func floatOperands(a, b interface{}) (float64, float64, bool) {
+	_, afloat := a.(float64)
+	_, bfloat := b.(float64)
+	if !afloat && !bfloat {
+		return 0, 0, false
+	}
+	fa, ok := toFloat(a)
+	if !ok {
+		return 0, 0, false
+	}
+	fb, ok := toFloat(b)
+	if !ok {
+		return 0, 0, false
+	}
+	return fa, fb, true
+}
+
+// floatResult provides the result of a float operation. Infinite or
+// undefined values cannot be represented in the generated documents
+// and are reported as evaluation error.
+func floatResult(v float64, info EvaluationInfo) (interface{}, EvaluationInfo, bool) {
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return info.Error("float value out of range")
+	}
+	return v, info, true
+}
@@ -0,0 +1,328 @@
+package dynaml
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pelletier/go-toml"
+
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+func init() {
+	RegisterFunction("astoml", formatFunction("astoml", yaml.ValueToTOML))
+	RegisterFunction("asenv", formatFunction("asenv", yaml.ValueToEnv))
+	RegisterFunction("asproperties", formatFunction("asproperties", yaml.ValueToProperties))
+	RegisterFunction("ashcl", formatFunction("ashcl", yaml.ValueToHCL))
+}
+
+// formatFunction provides a function marshalling its
+// argument to a string using the given writer.
+func formatFunction(name string, writer func(interface{}) ([]byte, error)) Function {
+	return func(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+		info := DefaultInfo()
+
+		if len(arguments) != 1 {
+			return info.Error("%s takes exactly one argument", name)
+		}
+		result, err := writer(arguments[0])
+		if err != nil {
+			return info.Error("%s: %s", name, err)
+		}
+		return string(result), info, true
+	}
+}
+
+// DataFormats maps the names of the supported foreign data formats
+// to their parsers. The parsed data uses the spiff value model.
+var DataFormats = map[string]func(data []byte) (interface{}, error){
+	"toml":       parseTOML,
+	"ini":        parseINI,
+	"env":        parseEnv,
+	"csv":        parseCSV,
+	"properties": parseProperties,
+	"xml":        parseXML,
+}
+
+// FileModes maps file suffixes to the read mode used for the mode auto.
+var FileModes = map[string]string{
+	".yml":        "yaml",
+	".yaml":       "yaml",
+	".json":       "yaml",
+	".toml":       "toml",
+	".ini":        "ini",
+	".env":        "env",
+	".csv":        "csv",
+	".properties": "properties",
+	".xml":        "xml",
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TOML
+
+func parseTOML(data []byte) (interface{}, error) {
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeTOML(tree.ToMap()), nil
+}
+
+func normalizeTOML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = normalizeTOML(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = normalizeTOML(e)
+		}
+	case []map[string]interface{}:
+		list := []interface{}{}
+		for _, e := range v {
+			list = append(list, normalizeTOML(e))
+		}
+		return list
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case int:
+		return int64(v)
+	case uint64:
+		return int64(v)
+	}
+	return value
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// INI
+
+func parseINI(data []byte) (interface{}, error) {
+	result := map[string]interface{}{}
+	section := result
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for no := 1; scanner.Scan(); no++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: invalid section header", no)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if s, ok := result[name].(map[string]interface{}); ok {
+				section = s
+			} else {
+				section = map[string]interface{}{}
+				result[name] = section
+			}
+			continue
+		}
+		i := strings.IndexAny(line, "=:")
+		if i <= 0 {
+			return nil, fmt.Errorf("line %d: key value pair required", no)
+		}
+		section[strings.TrimSpace(line[:i])] = unquote(strings.TrimSpace(line[i+1:]))
+	}
+	return result, scanner.Err()
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 {
+		if (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// dotenv
+
+func parseEnv(data []byte) (interface{}, error) {
+	result := map[string]interface{}{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for no := 1; scanner.Scan(); no++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("line %d: variable assignment required", no)
+		}
+		value, err := envValue(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", no, err)
+		}
+		result[strings.TrimSpace(line[:i])] = value
+	}
+	return result, scanner.Err()
+}
+
+func envValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		return value[1 : end+1], nil
+	case strings.HasPrefix(value, "\""):
+		result := []byte{}
+		for i := 1; i < len(value); i++ {
+			switch c := value[i]; c {
+			case '"':
+				return string(result), nil
+			case '\\':
+				i++
+				if i == len(value) {
+					break
+				}
+				switch value[i] {
+				case 'n':
+					result = append(result, '\n')
+				case 't':
+					result = append(result, '\t')
+				case 'r':
+					result = append(result, '\r')
+				default:
+					result = append(result, value[i])
+				}
+			default:
+				result = append(result, c)
+			}
+		}
+		return "", fmt.Errorf("unterminated quoted value")
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		return strings.TrimSpace(value), nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CSV
+
+func parseCSV(data []byte) (interface{}, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	result := []interface{}{}
+	if len(records) == 0 {
+		return result, nil
+	}
+	header := records[0]
+	for _, r := range records[1:] {
+		entry := map[string]interface{}{}
+		for i, f := range header {
+			entry[f] = r[i]
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Java properties
+
+func parseProperties(data []byte) (interface{}, error) {
+	loader := &properties.Loader{Encoding: properties.UTF8, DisableExpansion: true}
+	p, err := loader.LoadBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{}
+	for k, v := range p.Map() {
+		result[k] = v
+	}
+	return result, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// XML
+
+func parseXML(data []byte) (interface{}, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		t, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("no root element found")
+			}
+			return nil, err
+		}
+		if start, ok := t.(xml.StartElement); ok {
+			value, err := parseXMLElement(d, start)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{start.Name.Local: value}, nil
+		}
+	}
+}
+
+// parseXMLElement maps an element to a map of its attributes (prefixed
+// by @) and sub elements. Repeated sub elements are mapped to a list.
+// Text content is stored under #text, elements with text content only
+// are mapped to a string.
+func parseXMLElement(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	result := map[string]interface{}{}
+	lists := map[string]bool{}
+	text := ""
+
+	for _, a := range start.Attr {
+		if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+			continue
+		}
+		result["@"+a.Name.Local] = a.Value
+	}
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch e := t.(type) {
+		case xml.StartElement:
+			value, err := parseXMLElement(d, e)
+			if err != nil {
+				return nil, err
+			}
+			name := e.Name.Local
+			if old, ok := result[name]; ok {
+				if lists[name] {
+					result[name] = append(old.([]interface{}), value)
+				} else {
+					result[name] = []interface{}{old, value}
+					lists[name] = true
+				}
+			} else {
+				result[name] = value
+			}
+		case xml.CharData:
+			text += string(e)
+		case xml.EndElement:
+			text = strings.TrimSpace(text)
+			if len(result) == 0 {
+				return text, nil
+			}
+			if text != "" {
+				result["#text"] = text
+			}
+			return result, nil
+		}
+	}
+}
@@ -44,6 +44,8 @@ func _index(first bool, f func(string, string) int, arguments []interface{}, bin
 			return int64(f(val, elem)), info, true
 		case int64:
 			return int64(f(val, strconv.FormatInt(elem, 10))), info, true
+		case float64:
+			return int64(f(val, FloatString(elem))), info, true
 		case bool:
 			return int64(f(val, strconv.FormatBool(elem))), info, true
 		default:
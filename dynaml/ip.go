@@ -1,6 +1,8 @@
 package dynaml
 
 import (
+	"math"
+	"math/big"
 	"net"
 
 	"github.com/mandelsoft/spiff/yaml"
@@ -44,11 +46,57 @@ func func_maxIP(arguments []interface{}, binding Binding) (interface{}, Evaluati
 
 func func_numIP(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
 	return func_ip(func(ip net.IP, cidr *net.IPNet) interface{} {
-		ones, _ := cidr.Mask.Size()
-		return int64(1 << (32 - uint32(ones)))
+		return NumberValue(CIDRSize(cidr))
 	}, arguments, binding)
 }
 
+// NormalizeIP returns the 4 byte representation for IPv4 addresses
+// and the 16 byte representation for IPv6 addresses.
+func NormalizeIP(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip.To16()
+}
+
+// ParseIP parses an IPv4 or IPv6 address into its normalized
+// representation.
+func ParseIP(s string) net.IP {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil
+	}
+	return NormalizeIP(ip)
+}
+
+// CIDRSize returns the number of addresses of a network.
+func CIDRSize(cidr *net.IPNet) *big.Int {
+	ones, bits := cidr.Mask.Size()
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+}
+
+// NumberValue maps a big integer to an int64 value, if possible.
+// Larger values, for example the size of IPv6 networks,
+// are mapped to a float value.
+func NumberValue(n *big.Int) interface{} {
+	if n.IsInt64() {
+		return n.Int64()
+	}
+	f, _ := new(big.Float).SetInt(n).Float64()
+	return f
+}
+
+// Int64Size limits the size of an address range to the int64 range.
+func Int64Size(n *big.Int) int64 {
+	if n.IsInt64() {
+		return n.Int64()
+	}
+	if n.Sign() < 0 {
+		return math.MinInt64
+	}
+	return math.MaxInt64
+}
+
 func SubIP(ip net.IP, mask net.IPMask) net.IP {
 	m := ip.Mask(mask)
 	out := make(net.IP, len(ip))
@@ -79,11 +127,26 @@ func MaxIP(cidr *net.IPNet) net.IP {
 }
 
 func DiffIP(a, b net.IP) int64 {
-	var d int64
+	return Int64Size(DiffIPBig(a, b))
+}
 
-	for i, _ := range a {
-		db := int64(a[i]) - int64(b[i])
-		d = d*256 + db
-	}
-	return d
+// DiffIPBig returns the number of addresses between two IP addresses
+// of the same type.
+func DiffIPBig(a, b net.IP) *big.Int {
+	a = NormalizeIP(a)
+	b = NormalizeIP(b)
+	return new(big.Int).Sub(new(big.Int).SetBytes(a), new(big.Int).SetBytes(b))
+}
+
+// IPAddBig adds an offset to an IP address. The result wraps around
+// at the end of the address space.
+func IPAddBig(ip net.IP, offset *big.Int) net.IP {
+	ip = NormalizeIP(ip)
+	size := new(big.Int).Lsh(big.NewInt(1), uint(len(ip)*8))
+	n := new(big.Int).Add(new(big.Int).SetBytes(ip), offset)
+	n.Mod(n, size)
+	out := make(net.IP, len(ip))
+	b := n.Bytes()
+	copy(out[len(out)-len(b):], b)
+	return out
 }
@@ -2,6 +2,8 @@ package dynaml
 
 import (
 	"bytes"
+	"math"
+	"math/big"
 	"net"
 	"strings"
 
@@ -40,12 +42,12 @@ func map_ip_ranges(ranges []string) ([]IPRange, EvaluationInfo, bool) {
 		var ipr IPRange
 
 		if len(segments) == 1 {
-			_, cidr, err := net.ParseCIDR(r)
+			_, cidr, err := net.ParseCIDR(strings.TrimSpace(r))
 
 			if err == nil {
 				ipr = &cidrrange{*cidr}
 			} else {
-				start = net.ParseIP(strings.Trim(segments[0], " "))
+				start = ParseIP(strings.Trim(segments[0], " "))
 				if start == nil {
 					info.SetError("invalid IP '%s'", segments[0])
 					return nil, info, false
@@ -53,12 +55,12 @@ func map_ip_ranges(ranges []string) ([]IPRange, EvaluationInfo, bool) {
 				ipr = &iprange{start, start, int64(1)}
 			}
 		} else {
-			start = net.ParseIP(strings.Trim(segments[0], " "))
+			start = ParseIP(strings.Trim(segments[0], " "))
 			if start == nil {
 				info.SetError("invalid IP '%s'", segments[0])
 				return nil, info, false
 			}
-			end = net.ParseIP(strings.Trim(segments[1], " "))
+			end = ParseIP(strings.Trim(segments[1], " "))
 			if end == nil {
 				info.SetError("invalid IP '%s'", segments[1])
 				return nil, info, false
@@ -82,7 +84,7 @@ func map_ip_ranges(ranges []string) ([]IPRange, EvaluationInfo, bool) {
 
 func (i *iprange) GetSize() int64 {
 	if i.size == 0 {
-		i.size = DiffIP(i.end, i.start) + 1
+		i.size = Int64Size(new(big.Int).Add(DiffIPBig(i.end, i.start), big.NewInt(1)))
 	}
 	debug.Debug("sizeof(%s-%s)=%d", i.start, i.end, i.size)
 	return i.size
@@ -97,9 +99,10 @@ func (i *iprange) GetIP(index int64) net.IP {
 	return IPAdd(ip, int64(index))
 }
 
+// GetSize returns the size of the network, limited to the
+// int64 range for large IPv6 networks.
 func (i *cidrrange) GetSize() int64 {
-	ones, _ := i.Mask.Size()
-	return int64(1 << (32 - uint32(ones)))
+	return Int64Size(CIDRSize(&i.IPNet))
 }
 
 func (i *cidrrange) GetIP(index int64) net.IP {
@@ -109,6 +112,26 @@ func (i *cidrrange) GetIP(index int64) net.IP {
 	return IPAdd(i.IP.Mask(i.Mask), int64(index))
 }
 
+// getIPFromRanges returns the IP address for an index in the sequence of
+// addresses described by a list of ranges. If the index is out of range,
+// nil and the number of available addresses is returned.
+func getIPFromRanges(ranges []IPRange, index int64) (net.IP, int64) {
+	var offset int64
+	for j, r := range ranges {
+		if index-offset < r.GetSize() {
+			ip := r.GetIP(index - offset)
+			debug.Debug("ipset: get %d from range %d: %s", index-offset, j, ip)
+			return ip, 0
+		}
+		debug.Debug("ipset: skipping range %d: offset %d size %d", j, offset, r.GetSize())
+		if offset > math.MaxInt64-r.GetSize() {
+			return nil, math.MaxInt64
+		}
+		offset += r.GetSize()
+	}
+	return nil, offset
+}
+
 func func_ipset(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
 	info := DefaultInfo()
 
@@ -183,24 +206,12 @@ func func_ipset(arguments []interface{}, binding Binding) (interface{}, Evaluati
 			index = indices[i]
 		}
 
-		var offset int64
-		offset = 0
-		for j, r := range ranges {
-			if int64(index) < offset+r.GetSize() {
-				ip := r.GetIP(int64(index) - offset).String()
-				debug.Debug("ipset: get %d from range %d: %s",
-					int64(index)-offset, j, ip)
-				result[i] = NewNode(ip, nil)
-				break
-			}
-			debug.Debug("ipset: skipping range %d: offset %d size %d",
-				j, offset, r.GetSize())
-			offset += r.GetSize()
-		}
-		if result[i] == nil {
+		ip, available := getIPFromRanges(ranges, int64(index))
+		if ip == nil {
 			return info.Error("ip index %d (%d) out of range (%d IP(s) available in ranges)",
-				i, index, offset)
+				i, index, available)
 		}
+		result[i] = NewNode(ip.String(), nil)
 	}
 	return result, info, true
 }
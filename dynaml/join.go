@@ -21,6 +21,8 @@ func func_join(arguments []interface{}, binding Binding) (interface{}, Evaluatio
 			args = append(args, v)
 		case int64:
 			args = append(args, strconv.FormatInt(v, 10))
+		case float64:
+			args = append(args, FloatString(v))
 		case bool:
 			args = append(args, strconv.FormatBool(v))
 		case []yaml.Node:
@@ -33,6 +35,8 @@ func func_join(arguments []interface{}, binding Binding) (interface{}, Evaluatio
 					args = append(args, e)
 				case int64:
 					args = append(args, strconv.FormatInt(e, 10))
+				case float64:
+					args = append(args, FloatString(e))
 				case bool:
 					args = append(args, strconv.FormatBool(e))
 				default:
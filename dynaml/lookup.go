@@ -1,8 +1,8 @@
 package dynaml
 
 import (
+	"github.com/mandelsoft/spiff/vfs"
 	"github.com/mandelsoft/spiff/yaml"
-	"os"
 	"path/filepath"
 )
 
@@ -46,9 +46,13 @@ func func_lookup(directory bool, arguments []interface{}, binding Binding) (inte
 		return info.Error("lookup_file: first argument is empty string")
 	}
 
+	fs := fileSystem(binding)
 	result := []yaml.Node{}
 	if filepath.IsAbs(name) {
-		if checkExistence(name, directory) {
+		if err := policy(binding).CheckFile(fs, name); err != nil {
+			return info.Error("lookup: %s", err)
+		}
+		if checkExistence(binding, name, directory) {
 			result = append(result, NewNode(name, binding))
 		}
 		return result, info, true
@@ -57,7 +61,10 @@ func func_lookup(directory bool, arguments []interface{}, binding Binding) (inte
 	for _, d := range paths {
 		if d != "" {
 			p := d + "/" + name
-			if checkExistence(p, directory) {
+			if err := policy(binding).CheckFile(fs, p); err != nil {
+				return info.Error("lookup: %s", err)
+			}
+			if checkExistence(binding, p, directory) {
 				result = append(result, NewNode(p, binding))
 			}
 		}
@@ -65,10 +72,6 @@ func func_lookup(directory bool, arguments []interface{}, binding Binding) (inte
 	return result, info, true
 }
 
-func checkExistence(path string, directory bool) bool {
-	s, err := os.Stat(path)
-	if os.IsNotExist(err) || err != nil {
-		return false
-	}
-	return s.IsDir() == directory
+func checkExistence(binding Binding, path string, directory bool) bool {
+	return vfs.Exists(fileSystem(binding), path, directory)
 }
@@ -30,6 +30,8 @@ func func_makemap(arguments []interface{}, binding Binding) (interface{}, Evalua
 				key = elem
 			case int64:
 				key = strconv.FormatInt(elem, 10)
+			case float64:
+				key = FloatString(elem)
 			case bool:
 				key = strconv.FormatBool(elem)
 			default:
@@ -48,6 +50,8 @@ func func_makemap(arguments []interface{}, binding Binding) (interface{}, Evalua
 				key = elem
 			case int64:
 				key = strconv.FormatInt(elem, 10)
+			case float64:
+				key = FloatString(elem)
 			case bool:
 				key = strconv.FormatBool(elem)
 			default:
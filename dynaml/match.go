@@ -48,6 +48,8 @@ func func_match(arguments []interface{}, binding Binding) (interface{}, Evaluati
 		elem = v
 	case int64:
 		elem = strconv.FormatInt(v, 10)
+	case float64:
+		elem = FloatString(v)
 	case bool:
 		elem = strconv.FormatBool(v)
 	default:
@@ -2,6 +2,7 @@ package dynaml
 
 import (
 	"fmt"
+	"math"
 )
 
 type ModuloExpr struct {
@@ -12,12 +13,12 @@ type ModuloExpr struct {
 func (e ModuloExpr) Evaluate(binding Binding, locally bool) (interface{}, EvaluationInfo, bool) {
 	resolved := true
 
-	aint, info, ok := ResolveIntegerExpressionOrPushEvaluation(&e.A, &resolved, nil, binding, false)
+	a, info, ok := ResolveExpressionOrPushEvaluation(&e.A, &resolved, nil, binding, false)
 	if !ok {
 		return nil, info, false
 	}
 
-	bint, info, ok := ResolveIntegerExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
+	b, info, ok := ResolveExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
 	if !ok {
 		return nil, info, false
 	}
@@ -26,6 +27,19 @@ func (e ModuloExpr) Evaluate(binding Binding, locally bool) (interface{}, Evalua
 		return e, info, true
 	}
 
+	if fa, fb, ok := floatOperands(a, b); ok {
+		if fb == 0 {
+			return info.Error("division by zero")
+		}
+		return math.Mod(fa, fb), info, true
+	}
+
+	aint, aok := a.(int64)
+	bint, bok := b.(int64)
+	if !aok || !bok {
+		return info.Error("integer operand required")
+	}
+
 	if bint == 0 {
 		return info.Error("division by zero")
 	}
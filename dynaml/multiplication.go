@@ -2,6 +2,7 @@ package dynaml
 
 import (
 	"fmt"
+	"math/big"
 	"net"
 )
 
@@ -18,7 +19,7 @@ func (e MultiplicationExpr) Evaluate(binding Binding, locally bool) (interface{}
 		return nil, info, false
 	}
 
-	bint, info, ok := ResolveIntegerExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
+	b, info, ok := ResolveExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
 	if !ok {
 		return nil, info, false
 	}
@@ -27,6 +28,15 @@ func (e MultiplicationExpr) Evaluate(binding Binding, locally bool) (interface{}
 		return e, info, true
 	}
 
+	if fa, fb, ok := floatOperands(a, b); ok {
+		return floatResult(fa*fb, info)
+	}
+
+	bint, ok := b.(int64)
+	if !ok {
+		return info.Error("integer operand required")
+	}
+
 	aint, ok := a.(int64)
 	if ok {
 		return aint * bint, info, true
@@ -38,12 +48,11 @@ func (e MultiplicationExpr) Evaluate(binding Binding, locally bool) (interface{}
 		if err != nil {
 			return info.Error("CIDR or int argument required for multiplication: %s", err)
 		}
-		ones, _ := cidr.Mask.Size()
-		size := int64(1 << (32 - uint32(ones)))
-		ip = IPAdd(ip.Mask(cidr.Mask), size*bint)
+		offset := new(big.Int).Mul(CIDRSize(cidr), big.NewInt(bint))
+		ip = IPAddBig(ip.Mask(cidr.Mask), offset)
 		return (&net.IPNet{ip, cidr.Mask}).String(), info, true
 	}
-	return info.Error("CIDR or int argument required as first argument for multiplication")
+	return info.Error("CIDR or number argument required as first argument for multiplication")
 }
 
 func (e MultiplicationExpr) String() string {
@@ -15,6 +15,17 @@ var _ = Describe("multiplication", func() {
 		Expect(expr).To(EvaluateAs(6, FakeBinding{}))
 	})
 
+	Context("when the float result overflows", func() {
+		It("fails", func() {
+			expr := MultiplicationExpr{
+				FloatExpr{1.0e300},
+				FloatExpr{1.0e300},
+			}
+
+			Expect(expr).To(FailToEvaluate(FakeBinding{}))
+		})
+	})
+
 	Context("when the left-hand side is not an integer", func() {
 		It("fails", func() {
 			expr := MultiplicationExpr{
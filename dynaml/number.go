@@ -0,0 +1,161 @@
+package dynaml
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+func init() {
+	RegisterFunction("int", func_int)
+	RegisterFunction("float", func_float)
+	RegisterFunction("round", func_round)
+	RegisterFunction("floor", func_floor)
+	RegisterFunction("ceil", func_ceil)
+}
+
+func floatToInt(f float64) (int64, bool) {
+	if math.IsNaN(f) || f >= math.MaxInt64 || f < math.MinInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+func floatArgument(name string, arg interface{}) (float64, error) {
+	f, ok := arg.(float64)
+	if !ok {
+		return 0, fmt.Errorf("number argument required for function %s, but got %s", name, ExpressionType(arg))
+	}
+	return f, nil
+}
+
+func func_int(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("int takes exactly 1 argument")
+	}
+
+	switch v := arguments[0].(type) {
+	case int64:
+		return v, info, true
+	case float64:
+		i, ok := floatToInt(v)
+		if !ok {
+			return info.Error("float value %s out of integer range", FloatString(v))
+		}
+		return i, info, true
+	case bool:
+		if v {
+			return int64(1), info, true
+		}
+		return int64(0), info, true
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err == nil {
+			return i, info, true
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return info.Error("invalid integer value %q", v)
+		}
+		i, ok := floatToInt(f)
+		if !ok {
+			return info.Error("value %q out of integer range", v)
+		}
+		return i, info, true
+	default:
+		return info.Error("invalid type %s for function int", ExpressionType(v))
+	}
+}
+
+func func_float(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("float takes exactly 1 argument")
+	}
+
+	switch v := arguments[0].(type) {
+	case int64:
+		return float64(v), info, true
+	case float64:
+		return v, info, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return info.Error("invalid float value %q", v)
+		}
+		return floatResult(f, info)
+	default:
+		return info.Error("invalid type %s for function float", ExpressionType(v))
+	}
+}
+
+func func_round(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) < 1 || len(arguments) > 2 {
+		return info.Error("round takes one or two arguments")
+	}
+
+	if i, ok := arguments[0].(int64); ok {
+		return i, info, true
+	}
+	f, err := floatArgument("round", arguments[0])
+	if err != nil {
+		return info.Error("%s", err)
+	}
+	if len(arguments) == 2 {
+		digits, ok := arguments[1].(int64)
+		if !ok {
+			return info.Error("second argument of round must be an integer")
+		}
+		scale := math.Pow(10, float64(digits))
+		return floatResult(math.Round(f*scale)/scale, info)
+	}
+	return intResult("round", math.Round(f))
+}
+
+func func_floor(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("floor takes exactly 1 argument")
+	}
+
+	if i, ok := arguments[0].(int64); ok {
+		return i, info, true
+	}
+	f, err := floatArgument("floor", arguments[0])
+	if err != nil {
+		return info.Error("%s", err)
+	}
+	return intResult("floor", math.Floor(f))
+}
+
+func func_ceil(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("ceil takes exactly 1 argument")
+	}
+
+	if i, ok := arguments[0].(int64); ok {
+		return i, info, true
+	}
+	f, err := floatArgument("ceil", arguments[0])
+	if err != nil {
+		return info.Error("%s", err)
+	}
+	return intResult("ceil", math.Ceil(f))
+}
+
+func intResult(name string, f float64) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+	i, ok := floatToInt(f)
+	if !ok {
+		return info.Error("result of %s (%s) out of integer range", name, FloatString(f))
+	}
+	return i, info, true
+}
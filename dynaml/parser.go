@@ -256,6 +256,14 @@ func buildExpression(grammar *DynamlGrammar, path []string, stubPath []string) (
 			}
 
 			tokens.Push(IntegerExpr{val})
+		case ruleFloat:
+			val, err := strconv.ParseFloat(strings.Replace(contents, "_", "", -1), 64)
+			if err != nil && err.(*strconv.NumError).Err != strconv.ErrRange {
+				// out of range values are reported on evaluation
+				panic(err)
+			}
+
+			tokens.Push(FloatExpr{val})
 		case ruleNil:
 			tokens.Push(NilExpr{})
 		case ruleUndefined:
@@ -16,6 +16,25 @@ var _ = Describe("parsing", func() {
 		})
 	})
 
+	Describe("floats", func() {
+		It("parses positive numbers", func() {
+			parsesAs("1.5", FloatExpr{1.5})
+		})
+
+		It("parses negative numbers", func() {
+			parsesAs("-0.25", FloatExpr{-0.25})
+		})
+
+		It("parses exponents", func() {
+			parsesAs("1.5e3", FloatExpr{1500})
+			parsesAs("2.0E-2", FloatExpr{0.02})
+		})
+
+		It("does not interfere with ranges", func() {
+			parsesAs("[1..2]", RangeExpr{IntegerExpr{1}, IntegerExpr{2}})
+		})
+	})
+
 	Describe("strings", func() {
 		It("parses strings with escaped quotes", func() {
 			parsesAs(`"foo \"bar\" baz"`, StringExpr{`foo "bar" baz`})
@@ -0,0 +1,95 @@
+package passwd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const AES256GCM = "AES256GCM"
+
+// scrypt parameters used by version 1 of the ciphertext format
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	aesKeyLength = 32
+	saltLength   = 16
+)
+
+// aesgcm encrypts data with AES-256 in Galois/Counter mode using a
+// key derived from the password with scrypt. The result is prefixed
+// by a versioned header.
+type aesgcm struct {
+}
+
+func (e aesgcm) Name() string {
+	return AES256GCM
+}
+
+func (e aesgcm) header() string {
+	return Header(AES256GCM)
+}
+
+func (e aesgcm) aead(key string, salt []byte) (cipher.AEAD, error) {
+	k, err := scrypt.Key([]byte(key), salt, scryptN, scryptR, scryptP, aesKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	c, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(c)
+}
+
+func (e aesgcm) Encode(text string, key string) (string, error) {
+	salt := make([]byte, saltLength)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", err
+	}
+	gcm, err := e.aead(key, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	data := append(salt, nonce...)
+	data = gcm.Seal(data, nonce, []byte(text), []byte(e.header()))
+	return e.header() + hex.EncodeToString(data), nil
+}
+
+func (e aesgcm) Decode(text string, key string) (string, error) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, e.header()) {
+		return "", fmt.Errorf("missing %s header", AES256GCM)
+	}
+	data, err := hex.DecodeString(text[len(e.header()):])
+	if err != nil {
+		return "", fmt.Errorf("invalid ciphertext: %s", err)
+	}
+	if len(data) < saltLength {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+	gcm, err := e.aead(key, data[:saltLength])
+	if err != nil {
+		return "", err
+	}
+	data = data[saltLength:]
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(e.header()))
+	if err != nil {
+		return "", fmt.Errorf("invalid key or corrupted ciphertext")
+	}
+	return string(plain), nil
+}
@@ -2,6 +2,10 @@ package passwd
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
 	"github.com/cloudfoundry-incubator/candiedyaml"
 	. "github.com/mandelsoft/spiff/dynaml"
 )
@@ -14,8 +18,19 @@ type Encoding interface {
 
 var encodings = map[string]Encoding{
 	TRIPPLEDES: des1{},
+	AES256GCM:  aesgcm{},
 }
 
+// environment variables used to configure the default encryption key
+const (
+	KEY_ENV      = "SPIFF_ENCRYPTION_KEY"
+	KEY_FILE_ENV = "SPIFF_ENCRYPTION_KEY_FILE"
+)
+
+// HEADER_PREFIX is the prefix of the versioned header of ciphertexts
+// of encodings supporting method detection.
+const HEADER_PREFIX = "spiff:1:"
+
 const F_Decrypt = "decrypt"
 const F_Encrypt = "encrypt"
 
@@ -32,6 +47,48 @@ func GetEncoding(name string) Encoding {
 	return encodings[name]
 }
 
+// Header provides the versioned ciphertext header for an encoding.
+func Header(method string) string {
+	return HEADER_PREFIX + method + ":"
+}
+
+// DetectEncoding determines the encoding method of a ciphertext with
+// a versioned header. For other ciphertexts an empty string is returned.
+func DetectEncoding(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, HEADER_PREFIX) {
+		return ""
+	}
+	text = text[len(HEADER_PREFIX):]
+	i := strings.Index(text, ":")
+	if i < 0 {
+		return ""
+	}
+	return text[:i]
+}
+
+// ReadKeyFile reads an encryption key from a file. Trailing line
+// breaks are ignored.
+func ReadKeyFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read key file: %s", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// EnvironmentKey provides the default encryption key configured by
+// the environment, either directly or by a key file.
+func EnvironmentKey() (string, error) {
+	if key := os.Getenv(KEY_ENV); key != "" {
+		return key, nil
+	}
+	if path := os.Getenv(KEY_FILE_ENV); path != "" {
+		return ReadKeyFile(path)
+	}
+	return "", nil
+}
+
 func func_decrypt(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
 	info := DefaultInfo()
 	if len(arguments) < 1 || len(arguments) > 3 {
@@ -44,7 +101,10 @@ func func_decrypt(arguments []interface{}, binding Binding) (interface{}, Evalua
 	}
 
 	key := binding.GetState().GetEncryptionKey()
-	method := TRIPPLEDES
+	method := DetectEncoding(value)
+	if method == "" {
+		method = TRIPPLEDES
+	}
 	v := ""
 	if len(arguments) > 1 {
 		v, err = StringValue(fmt.Sprintf("%s: 2nd argument", F_Decrypt), arguments[1])
@@ -65,6 +125,7 @@ func func_decrypt(arguments []interface{}, binding Binding) (interface{}, Evalua
 		if err != nil {
 			return info.Error(err)
 		}
+		key = v
 		method = m
 	}
 
@@ -117,6 +178,7 @@ func func_encrypt(arguments []interface{}, binding Binding) (interface{}, Evalua
 		if err != nil {
 			return info.Error(err)
 		}
+		key = v
 		method = m
 	}
 
@@ -0,0 +1,83 @@
+package passwd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// legacyCiphertext has been created with the 3DES encoding of
+// spiff versions without ciphertext headers
+const legacyCiphertext = "ec7d474d5b2a2d67623419189f6abaf121ef10393df41d0c8fd560222a1994372b0833814ca1ed03467ae494f8a73b5ce3581402235116c8"
+
+var _ = Describe("encryption", func() {
+	Context("detecting the encoding", func() {
+		It("detects versioned headers", func() {
+			text, err := GetEncoding(AES256GCM).Encode("alice: 25\n", "secret")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(DetectEncoding(text)).To(Equal(AES256GCM))
+			Expect(DetectEncoding("  " + text + "\n")).To(Equal(AES256GCM))
+		})
+
+		It("detects nothing for legacy 3DES ciphertexts", func() {
+			Expect(DetectEncoding(legacyCiphertext)).To(Equal(""))
+			Expect(DetectEncoding(legacyCiphertext + "\n")).To(Equal(""))
+
+			text, err := GetEncoding(TRIPPLEDES).Decode(legacyCiphertext, "secret")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(text).To(Equal("alice: 25\n"))
+		})
+
+		It("detects nothing for incomplete headers", func() {
+			Expect(DetectEncoding(HEADER_PREFIX + "AES256GCM")).To(Equal(""))
+		})
+	})
+
+	Context("reading key files", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "passwd")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("ignores trailing line breaks", func() {
+			file := filepath.Join(dir, "key")
+			Expect(ioutil.WriteFile(file, []byte("my secret\r\n\n"), 0600)).To(Succeed())
+			Expect(ReadKeyFile(file)).To(Equal("my secret"))
+		})
+
+		It("keeps other whitespace", func() {
+			file := filepath.Join(dir, "key")
+			Expect(ioutil.WriteFile(file, []byte(" my secret \n"), 0600)).To(Succeed())
+			Expect(ReadKeyFile(file)).To(Equal(" my secret "))
+		})
+
+		It("fails for missing files", func() {
+			_, err := ReadKeyFile(filepath.Join(dir, "missing"))
+			Expect(err).To(MatchError(ContainSubstring("cannot read key file")))
+		})
+
+		It("is used as default key by the environment", func() {
+			file := filepath.Join(dir, "key")
+			Expect(ioutil.WriteFile(file, []byte("file secret\n"), 0600)).To(Succeed())
+			defer os.Setenv(KEY_ENV, os.Getenv(KEY_ENV))
+			defer os.Setenv(KEY_FILE_ENV, os.Getenv(KEY_FILE_ENV))
+
+			os.Setenv(KEY_ENV, "")
+			os.Setenv(KEY_FILE_ENV, file)
+			Expect(EnvironmentKey()).To(Equal("file secret"))
+
+			os.Setenv(KEY_ENV, "env secret")
+			Expect(EnvironmentKey()).To(Equal("env secret"))
+		})
+	})
+})
@@ -45,6 +45,9 @@ func func_pipe(cached bool, arguments []interface{}, binding Binding) (interface
 			args = append(args, v)
 		}
 	}
+	if err := policy(binding).CheckCommand(args[1]); err != nil {
+		return info.Error("pipe: %s", err)
+	}
 	result, err := cachedExecute(cached, &args[0], args[1:])
 	if err != nil {
 		return info.Error("execution '%s' failed", args[1])
@@ -0,0 +1,150 @@
+package dynaml
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mandelsoft/spiff/vfs"
+)
+
+// Policy restricts the access of dynaml functions to the environment
+// of the processing, like commands, files, URLs and environment variables.
+// A nil policy does not restrict anything, otherwise everything not
+// explicitly allowed is denied.
+type Policy struct {
+	// Commands lists the commands allowed for exec and pipe. Entries
+	// containing a slash match the exact command path, otherwise
+	// only the command name looked up via the search path is matched.
+	Commands []string
+	// Directories lists the directory trees allowed for file access.
+	Directories []string
+	// URLs lists the allowed URLs for reading content. Every URL allows
+	// the access to the URLs of its path tree on the same host.
+	URLs []string
+	// EnvVars lists the environment variables accessible by env.
+	EnvVars []string
+}
+
+// NewSandbox provides a policy denying all external access.
+func NewSandbox() *Policy {
+	return &Policy{}
+}
+
+func denied(kind string, name string) error {
+	return fmt.Errorf("%s %q denied by policy", kind, name)
+}
+
+func (p *Policy) CheckCommand(cmd string) error {
+	if p == nil {
+		return nil
+	}
+	for _, c := range p.Commands {
+		if strings.Contains(c, "/") {
+			if strings.Contains(cmd, "/") && filepath.Clean(c) == filepath.Clean(cmd) {
+				return nil
+			}
+		} else {
+			if c == cmd {
+				return nil
+			}
+		}
+	}
+	return denied("command", cmd)
+}
+
+// CheckFile checks the access to a file or directory. For the file system
+// of the operating system symbolic links are resolved before matching
+// the allowed directories.
+func (p *Policy) CheckFile(fs vfs.FileSystem, name string) error {
+	if p == nil {
+		return nil
+	}
+	resolved := resolvePath(fs, name)
+	for _, d := range p.Directories {
+		dir := resolvePath(fs, d)
+		if resolved == dir || strings.HasPrefix(resolved, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator)) {
+			return nil
+		}
+	}
+	return denied("path", name)
+}
+
+func resolvePath(fs vfs.FileSystem, name string) string {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		abs = filepath.Clean(name)
+	}
+	if fs != vfs.OSFileSystem {
+		return abs
+	}
+	return evalSymlinks(abs)
+}
+
+// evalSymlinks resolves the symbolic links of the existing part of a path.
+func evalSymlinks(name string) string {
+	if resolved, err := filepath.EvalSymlinks(name); err == nil {
+		return resolved
+	}
+	dir, base := filepath.Split(name)
+	dir = filepath.Clean(dir)
+	if dir == name {
+		return name
+	}
+	return filepath.Join(evalSymlinks(dir), base)
+}
+
+// CheckURL checks the access to a URL. Scheme and host (including the
+// port) must match an allowed URL exactly, and the path must be in the
+// path tree of the allowed URL.
+func (p *Policy) CheckURL(name string) error {
+	if p == nil {
+		return nil
+	}
+	u, err := url.Parse(name)
+	if err == nil {
+		for _, a := range p.URLs {
+			if urlMatches(a, u) {
+				return nil
+			}
+		}
+	}
+	return denied("URL", name)
+}
+
+func urlMatches(allowed string, u *url.URL) bool {
+	a, err := url.Parse(allowed)
+	if err != nil || a.Host == "" || u.User != nil {
+		return false
+	}
+	if !strings.EqualFold(a.Scheme, u.Scheme) || !strings.EqualFold(a.Host, u.Host) {
+		return false
+	}
+	prefix := strings.TrimSuffix(path.Clean("/"+a.Path), "/")
+	name := path.Clean("/" + u.Path)
+	return prefix == "" || name == prefix || strings.HasPrefix(name, prefix+"/")
+}
+
+func (p *Policy) CheckEnv(name string) error {
+	if p == nil {
+		return nil
+	}
+	for _, e := range p.EnvVars {
+		if e == name {
+			return nil
+		}
+	}
+	return denied("environment variable", name)
+}
+
+// policy returns the policy to use for the processing of a binding.
+func policy(binding Binding) *Policy {
+	if binding != nil {
+		if state := binding.GetState(); state != nil {
+			return state.GetPolicy()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package dynaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/spiff/vfs"
+)
+
+var _ = Describe("policies", func() {
+	fs := vfs.NewMemoryFileSystem()
+
+	It("grants everything without policy", func() {
+		var p *Policy
+		Expect(p.CheckCommand("rm")).To(Succeed())
+		Expect(p.CheckFile(fs, "/etc/passwd")).To(Succeed())
+		Expect(p.CheckURL("http://example.com")).To(Succeed())
+		Expect(p.CheckEnv("HOME")).To(Succeed())
+	})
+
+	It("denies everything in sandbox", func() {
+		p := NewSandbox()
+		Expect(p.CheckCommand("rm")).To(MatchError(`command "rm" denied by policy`))
+		Expect(p.CheckFile(fs, "/etc/passwd")).To(MatchError(`path "/etc/passwd" denied by policy`))
+		Expect(p.CheckURL("http://example.com")).To(MatchError(`URL "http://example.com" denied by policy`))
+		Expect(p.CheckEnv("HOME")).To(MatchError(`environment variable "HOME" denied by policy`))
+	})
+
+	It("matches commands", func() {
+		p := &Policy{Commands: []string{"git", "/usr/bin/echo"}}
+		Expect(p.CheckCommand("git")).To(Succeed())
+		Expect(p.CheckCommand("/tmp/git")).NotTo(Succeed())
+		Expect(p.CheckCommand("/usr/bin/../bin/echo")).To(Succeed())
+		Expect(p.CheckCommand("echo")).NotTo(Succeed())
+	})
+
+	It("matches directory trees", func() {
+		p := &Policy{Directories: []string{"/data/"}}
+		Expect(p.CheckFile(fs, "/data")).To(Succeed())
+		Expect(p.CheckFile(fs, "/data/sub/file")).To(Succeed())
+		Expect(p.CheckFile(fs, "/data/../etc/passwd")).NotTo(Succeed())
+		Expect(p.CheckFile(fs, "/database")).NotTo(Succeed())
+	})
+
+	It("matches URL prefixes", func() {
+		p := &Policy{URLs: []string{"https://example.com/"}}
+		Expect(p.CheckURL("https://example.com/file")).To(Succeed())
+		Expect(p.CheckURL("https://example.com.evil/file")).NotTo(Succeed())
+	})
+
+	It("matches scheme and host of URLs exactly", func() {
+		p := &Policy{URLs: []string{"https://example.com"}}
+		Expect(p.CheckURL("https://example.com")).To(Succeed())
+		Expect(p.CheckURL("https://example.com/file")).To(Succeed())
+		Expect(p.CheckURL("https://example.com.evil.net/")).NotTo(Succeed())
+		Expect(p.CheckURL("https://example.com@evil.net/")).NotTo(Succeed())
+		Expect(p.CheckURL("https://example.com:8443/")).NotTo(Succeed())
+		Expect(p.CheckURL("http://example.com/")).NotTo(Succeed())
+	})
+
+	It("matches URL paths at segment boundaries", func() {
+		p := &Policy{URLs: []string{"https://example.com:8443/data"}}
+		Expect(p.CheckURL("https://example.com:8443/data")).To(Succeed())
+		Expect(p.CheckURL("https://example.com:8443/data/file")).To(Succeed())
+		Expect(p.CheckURL("https://example.com:8443/database")).NotTo(Succeed())
+		Expect(p.CheckURL("https://example.com:8443/data/../secret")).NotTo(Succeed())
+		Expect(p.CheckURL("https://example.com/data/file")).NotTo(Succeed())
+	})
+})
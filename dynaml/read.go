@@ -32,7 +32,12 @@ func func_read(cached bool, arguments []interface{}, binding Binding) (interface
 		if !ok {
 			return info.Error("string value required for type")
 		}
-
+		if t == "auto" {
+			t = "text"
+			if m, ok := FileModes[strings.ToLower(path.Ext(file))]; ok {
+				t = m
+			}
+		}
 	}
 
 	data, err := binding.GetFileContent(file, cached)
@@ -120,6 +125,18 @@ func ParseData(file string, data []byte, mode string, binding Binding) (interfac
 	case "text":
 		return string(data), info, true
 
+	case "toml", "ini", "env", "csv", "properties", "xml":
+		value, err := DataFormats[mode](data)
+		if err != nil {
+			return info.Error("error parsing %s file [%s]: %s", mode, path.Clean(file), err)
+		}
+		node, err := yaml.Sanitize(file, value)
+		if err != nil {
+			return info.Error("error parsing %s file [%s]: %s", mode, path.Clean(file), err)
+		}
+		info.Raw = true
+		return node.Value(), info, true
+
 	case "binary":
 		return Base64Encode(data, 60), info, true
 
@@ -0,0 +1,94 @@
+package dynaml
+
+import (
+	"reflect"
+
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+// References determines the reference paths used by an expression.
+// Lambda expressions are not inspected, because references in their
+// body are resolved in the context of the call. Also the relative
+// references of qualified expressions are omitted.
+func References(e Expression) [][]string {
+	c := &referenceCollector{complete: true}
+	c.collect(reflect.ValueOf(e))
+	return c.refs
+}
+
+// Dependencies determines the references an expression depends on.
+// In contrast to References, names of builtin functions are omitted.
+// Additionally it reports whether the result of the expression is
+// completely determined by the values of those references. This is not
+// the case for expressions evaluating references in a context not known
+// before their evaluation, like lambda calls, template instantiations,
+// dynamic references, local scopes or merges.
+func Dependencies(e Expression) ([][]string, bool) {
+	c := &referenceCollector{complete: true, dependencies: true}
+	c.collect(reflect.ValueOf(e))
+	return c.refs, c.complete
+}
+
+type referenceCollector struct {
+	refs         [][]string
+	complete     bool
+	dependencies bool
+}
+
+func (c *referenceCollector) collect(v reflect.Value) {
+	if !v.IsValid() {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if !v.IsNil() {
+			c.collect(v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			c.collect(v.Index(i))
+		}
+	case reflect.Struct:
+		if !v.CanInterface() {
+			return
+		}
+		switch e := v.Interface().(type) {
+		case ReferenceExpr:
+			if len(e.Path) == 0 || e.Path[0] == yaml.SELF || e.Path[0] == yaml.DOCNODE || e.Path[0] == "__ctx" {
+				c.complete = false
+			}
+			c.refs = append(c.refs, e.Path)
+			return
+		case QualifiedExpr:
+			c.complete = false
+			c.collect(reflect.ValueOf(e.Expression))
+			return
+		case CallExpr:
+			ref, ok := e.Function.(ReferenceExpr)
+			if !ok || len(ref.Path) != 1 || ref.Path[0] == "" || ref.Path[0] == yaml.SELF {
+				// lambda call
+				c.complete = false
+				break
+			}
+			switch ref.Path[0] {
+			case "eval", "static_ips", "stub", "sync":
+				// functions evaluating references given by their context
+				c.complete = false
+			}
+			if c.dependencies {
+				c.collect(reflect.ValueOf(e.Arguments))
+				return
+			}
+		case LambdaExpr, LambdaRefExpr, ValueExpr:
+			c.complete = false
+			return
+		case DynamicExpr, SubstitutionExpr, ScopeExpr, MergeExpr, MarkerExpr, ProjectionExpr:
+			c.complete = false
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				c.collect(v.Field(i))
+			}
+		}
+	}
+}
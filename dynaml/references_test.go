@@ -0,0 +1,37 @@
+package dynaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("references", func() {
+	It("collects references of nested expressions", func() {
+		expr, err := Parse(`a.b + join(",", c, [ d ]) || e.[0]`, []string{"x"}, []string{"x"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(References(expr)).To(Equal([][]string{
+			{"a", "b"}, {"join"}, {"c"}, {"d"}, {"e", "[0]"},
+		}))
+	})
+
+	It("ignores lambda bodies and qualified references", func() {
+		expr, err := Parse(`map[list|x|->x.y] (f).g`, []string{"x"}, []string{"x"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(References(expr)).To(Equal([][]string{{"list"}, {"f"}}))
+	})
+
+	It("omits builtin function names from dependencies", func() {
+		expr, err := Parse(`a.b + length(c)`, []string{"x"}, []string{"x"})
+		Expect(err).NotTo(HaveOccurred())
+		refs, complete := Dependencies(expr)
+		Expect(refs).To(Equal([][]string{{"a", "b"}, {"c"}}))
+		Expect(complete).To(BeTrue())
+	})
+
+	It("reports incomplete dependencies for lambda calls", func() {
+		expr, err := Parse(`.f(a)`, []string{"x"}, []string{"x"})
+		Expect(err).NotTo(HaveOccurred())
+		_, complete := Dependencies(expr)
+		Expect(complete).To(BeFalse())
+	})
+})
@@ -12,10 +12,13 @@ func func_sort(arguments []interface{}, binding Binding) (result interface{}, in
 		return info.Error("sort takes one or two arguments")
 	}
 
-	list, ok := arguments[0].([]yaml.Node)
+	orig, ok := arguments[0].([]yaml.Node)
 	if !ok {
 		return info.Error("argument for sort must be a list")
 	}
+	// sort a copy to keep the referenced list untouched
+	list := make([]yaml.Node, len(orig))
+	copy(list, orig)
 
 	var less Less
 
@@ -50,8 +53,17 @@ func ValueLess(list []yaml.Node) Less {
 			if ok {
 				return a < b
 			}
+			b2, ok := list[j].Value().(float64)
+			if ok {
+				return float64(a) < b2
+			}
+		case float64:
+			b, ok := toFloat(list[j].Value())
+			if ok {
+				return a < b
+			}
 		}
-		RaiseEvaluationErrorf("list elements must either be strings or integers")
+		RaiseEvaluationErrorf("list elements must either be strings or numbers")
 		return false
 	}
 }
@@ -1,9 +1,6 @@
 package dynaml
 
 import (
-	"net"
-	"strings"
-
 	"github.com/mandelsoft/spiff/yaml"
 )
 
@@ -77,18 +74,19 @@ func generateStaticIPs(binding Binding, indices []int) (interface{}, EvaluationI
 		return nil, info, ok
 	}
 	instanceCount := int(*instanceCountP)
-	ipPool, ok := staticIPPool(ranges)
+	ipPool, info, ok := map_ip_ranges(ranges)
 	if !ok {
 		return nil, info, false
 	}
 
 	ips := []yaml.Node{}
 	for _, i := range indices {
-		if len(ipPool) <= i {
+		ip, _ := getIPFromRanges(ipPool, int64(i))
+		if ip == nil {
 			return nil, info, false
 		}
 
-		ips = append(ips, NewNode(ipPool[i].String(), binding))
+		ips = append(ips, NewNode(ip.String(), binding))
 	}
 
 	if len(ips) < instanceCount {
@@ -175,52 +173,3 @@ func findStaticIPRanges(binding Binding) ([]string, EvaluationInfo, bool) {
 
 	return allRanges, info, true
 }
-
-func staticIPPool(ranges []string) ([]net.IP, bool) {
-	ipPool := []net.IP{}
-
-	for _, r := range ranges {
-		segments := strings.Split(r, "-")
-		if len(segments) == 0 {
-			return nil, false
-		}
-
-		var start, end net.IP
-
-		start = net.ParseIP(strings.Trim(segments[0], " "))
-
-		if len(segments) == 1 {
-			end = start
-		} else {
-			end = net.ParseIP(strings.Trim(segments[1], " "))
-		}
-
-		ipPool = append(ipPool, ipRange(start, end)...)
-	}
-
-	return ipPool, true
-}
-
-func ipRange(a, b net.IP) []net.IP {
-	prev := a
-
-	ips := []net.IP{a}
-
-	for !prev.Equal(b) {
-		next := net.ParseIP(prev.String())
-		inc(next)
-		ips = append(ips, next)
-		prev = next
-	}
-
-	return ips
-}
-
-func inc(ip net.IP) {
-	for j := len(ip) - 1; j >= 0; j-- {
-		ip[j]++
-		if ip[j] > 0 {
-			break
-		}
-	}
-}
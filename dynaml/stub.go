@@ -1,6 +1,7 @@
 package dynaml
 
 import (
+	"github.com/mandelsoft/spiff/debug"
 	"github.com/mandelsoft/spiff/yaml"
 	"strings"
 )
@@ -52,5 +53,6 @@ func (e CallExpr) stub(binding Binding) (interface{}, EvaluationInfo, bool) {
 	if !ok {
 		return info.Error("'%s' not found in any stub", strings.Join(arg, "."))
 	}
+	debug.Debug("stub %s found in %s\n", strings.Join(arg, "."), stub.SourceLocation())
 	return stub.Value(), info, ok
 }
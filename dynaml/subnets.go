@@ -0,0 +1,281 @@
+package dynaml
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+// MaxSubnets limits the number of subnets generated by a single
+// call of the subnets function.
+const MaxSubnets = 65536
+
+func init() {
+	RegisterFunction("subnets", func_subnets)
+	RegisterFunction("subnet", func_subnet)
+	RegisterFunction("contains_ip", func_containsIP)
+	RegisterFunction("netmask", func_netmask)
+	RegisterFunction("wildcard_mask", func_wildcardMask)
+	RegisterFunction("broadcast_ip", func_broadcastIP)
+	RegisterFunction("aggregate_cidrs", func_aggregateCIDRs)
+}
+
+func cidrArgument(arg interface{}) (*net.IPNet, bool) {
+	s, ok := arg.(string)
+	if !ok {
+		return nil, false
+	}
+	_, cidr, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, false
+	}
+	cidr.IP = NormalizeIP(cidr.IP)
+	return cidr, true
+}
+
+// subnetOf returns the index-th subnet with the given prefix length
+// of a network.
+func subnetOf(cidr *net.IPNet, prefix int, index *big.Int) *net.IPNet {
+	_, bits := cidr.Mask.Size()
+	offset := new(big.Int).Lsh(index, uint(bits-prefix))
+	return &net.IPNet{
+		IP:   IPAddBig(cidr.IP, offset),
+		Mask: net.CIDRMask(prefix, bits),
+	}
+}
+
+func subnetPrefix(name string, cidr *net.IPNet, arg interface{}) (int, error) {
+	prefix, ok := arg.(int64)
+	ones, bits := cidr.Mask.Size()
+	if !ok {
+		return 0, fmt.Errorf("%s: prefix length must be an integer", name)
+	}
+	if prefix < int64(ones) || prefix > int64(bits) {
+		return 0, fmt.Errorf("%s: prefix length %d out of range (%d-%d)", name, prefix, ones, bits)
+	}
+	return int(prefix), nil
+}
+
+func func_subnets(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) < 2 || len(arguments) > 3 {
+		return info.Error("subnets requires two or three arguments (cidr, prefix length, optional: count)")
+	}
+	cidr, ok := cidrArgument(arguments[0])
+	if !ok {
+		return info.Error("subnets: CIDR argument required")
+	}
+	prefix, err := subnetPrefix("subnets", cidr, arguments[1])
+	if err != nil {
+		return info.Error("%s", err)
+	}
+	ones, _ := cidr.Mask.Size()
+	available := new(big.Int).Lsh(big.NewInt(1), uint(prefix-ones))
+	count := available
+	if len(arguments) == 3 {
+		n, ok := arguments[2].(int64)
+		if !ok || n < 0 {
+			return info.Error("subnets: count must be a non-negative integer")
+		}
+		count = big.NewInt(n)
+		if count.Cmp(available) > 0 {
+			return info.Error("subnets: %d subnets with prefix length %d requested, but only %s available in %s", n, prefix, available, cidr)
+		}
+	}
+	if count.Cmp(big.NewInt(MaxSubnets)) > 0 {
+		return info.Error("subnets: too many subnets (%s > %d)", count, MaxSubnets)
+	}
+
+	result := make([]yaml.Node, count.Int64())
+	for i := range result {
+		result[i] = NewNode(subnetOf(cidr, prefix, big.NewInt(int64(i))).String(), binding)
+	}
+	return result, info, true
+}
+
+func func_subnet(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 3 {
+		return info.Error("subnet requires three arguments (cidr, prefix length, index)")
+	}
+	cidr, ok := cidrArgument(arguments[0])
+	if !ok {
+		return info.Error("subnet: CIDR argument required")
+	}
+	prefix, err := subnetPrefix("subnet", cidr, arguments[1])
+	if err != nil {
+		return info.Error("%s", err)
+	}
+	index, ok := arguments[2].(int64)
+	if !ok {
+		return info.Error("subnet: index must be an integer")
+	}
+	ones, _ := cidr.Mask.Size()
+	available := new(big.Int).Lsh(big.NewInt(1), uint(prefix-ones))
+	n := big.NewInt(index)
+	if n.Sign() < 0 {
+		n.Add(n, available)
+	}
+	if n.Sign() < 0 || n.Cmp(available) >= 0 {
+		return info.Error("subnet: index %d out of range (%s subnets with prefix length %d available in %s)", index, available, prefix, cidr)
+	}
+	return subnetOf(cidr, prefix, n).String(), info, true
+}
+
+func func_containsIP(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 2 {
+		return info.Error("contains_ip requires two arguments (cidr, ip or cidr)")
+	}
+	cidr, ok := cidrArgument(arguments[0])
+	if !ok {
+		return info.Error("contains_ip: CIDR argument required")
+	}
+	s, ok := arguments[1].(string)
+	if !ok {
+		return info.Error("contains_ip: IP address or CIDR required as second argument")
+	}
+	if sub, ok := cidrArgument(s); ok {
+		subOnes, _ := sub.Mask.Size()
+		ones, _ := cidr.Mask.Size()
+		return len(sub.IP) == len(cidr.IP) && subOnes >= ones && cidr.Contains(sub.IP), info, true
+	}
+	ip := ParseIP(s)
+	if ip == nil {
+		return info.Error("contains_ip: IP address or CIDR required as second argument")
+	}
+	return len(ip) == len(cidr.IP) && cidr.Contains(ip), info, true
+}
+
+func func_netmask(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	return func_ip(func(ip net.IP, cidr *net.IPNet) interface{} {
+		return net.IP(cidr.Mask).String()
+	}, arguments, binding)
+}
+
+func func_wildcardMask(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	return func_ip(func(ip net.IP, cidr *net.IPNet) interface{} {
+		mask := make(net.IP, len(cidr.Mask))
+		for i, b := range cidr.Mask {
+			mask[i] = ^b
+		}
+		return mask.String()
+	}, arguments, binding)
+}
+
+func func_broadcastIP(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) == 1 {
+		if cidr, ok := cidrArgument(arguments[0]); ok && len(cidr.IP) != net.IPv4len {
+			return info.Error("broadcast_ip: IPv6 networks have no broadcast address")
+		}
+	}
+	return func_maxIP(arguments, binding)
+}
+
+type ipInterval struct {
+	start *big.Int
+	end   *big.Int
+	size  int
+}
+
+func func_aggregateCIDRs(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	intervals := []ipInterval{}
+	var add func(arg interface{}) bool
+	add = func(arg interface{}) bool {
+		switch v := arg.(type) {
+		case string:
+			cidr, ok := cidrArgument(v)
+			if !ok {
+				return false
+			}
+			start := new(big.Int).SetBytes(cidr.IP)
+			end := new(big.Int).Add(start, CIDRSize(cidr))
+			intervals = append(intervals, ipInterval{start, end.Sub(end, big.NewInt(1)), len(cidr.IP)})
+		case []yaml.Node:
+			for _, e := range v {
+				if !add(e.Value()) {
+					return false
+				}
+			}
+		default:
+			return false
+		}
+		return true
+	}
+	for _, a := range arguments {
+		if !add(a) {
+			return info.Error("aggregate_cidrs: CIDRs or lists of CIDRs required")
+		}
+	}
+
+	sort.Slice(intervals, func(i, j int) bool {
+		if intervals[i].size != intervals[j].size {
+			return intervals[i].size < intervals[j].size
+		}
+		return intervals[i].start.Cmp(intervals[j].start) < 0
+	})
+
+	merged := []ipInterval{}
+	for _, i := range intervals {
+		if len(merged) > 0 {
+			last := &merged[len(merged)-1]
+			next := new(big.Int).Add(last.end, big.NewInt(1))
+			if last.size == i.size && i.start.Cmp(next) <= 0 {
+				if i.end.Cmp(last.end) > 0 {
+					last.end = i.end
+				}
+				continue
+			}
+		}
+		merged = append(merged, i)
+	}
+
+	result := []yaml.Node{}
+	for _, i := range merged {
+		for _, cidr := range intervalCIDRs(i) {
+			result = append(result, NewNode(cidr.String(), binding))
+		}
+	}
+	return result, info, true
+}
+
+// intervalCIDRs returns the minimal list of CIDRs covering
+// an address interval.
+func intervalCIDRs(i ipInterval) []*net.IPNet {
+	bits := i.size * 8
+	result := []*net.IPNet{}
+	start := new(big.Int).Set(i.start)
+	one := big.NewInt(1)
+	for start.Cmp(i.end) <= 0 {
+		// largest block aligned at start
+		host := 0
+		for host < bits && start.Bit(host) == 0 {
+			host++
+		}
+		// shrink block to fit into the interval
+		for host > 0 {
+			last := new(big.Int).Lsh(one, uint(host))
+			last.Add(last, start).Sub(last, one)
+			if last.Cmp(i.end) <= 0 {
+				break
+			}
+			host--
+		}
+		ip := make(net.IP, i.size)
+		b := start.Bytes()
+		copy(ip[len(ip)-len(b):], b)
+		result = append(result, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits-host, bits)})
+		start.Add(start, new(big.Int).Lsh(one, uint(host)))
+	}
+	return result
+}
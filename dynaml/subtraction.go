@@ -2,7 +2,6 @@ package dynaml
 
 import (
 	"fmt"
-	"net"
 )
 
 type SubtractionExpr struct {
@@ -27,6 +26,10 @@ func (e SubtractionExpr) Evaluate(binding Binding, locally bool) (interface{}, E
 		return e, info, true
 	}
 
+	if fa, fb, ok := floatOperands(a, b); ok {
+		return floatResult(fa-fb, info)
+	}
+
 	aint, ok := a.(int64)
 	bint, bok := b.(int64)
 	if ok {
@@ -38,19 +41,19 @@ func (e SubtractionExpr) Evaluate(binding Binding, locally bool) (interface{}, E
 
 	str, ok := a.(string)
 	if ok {
-		ip := net.ParseIP(str)
+		ip := ParseIP(str)
 		if ip != nil {
 			if bok {
 				return IPAdd(ip, -bint).String(), info, true
 			}
 			bstr, ok := b.(string)
 			if ok {
-				ipb := net.ParseIP(bstr)
-				if ip != nil {
+				ipb := ParseIP(bstr)
+				if ipb != nil {
 					if len(ip) != len(ipb) {
 						return info.Error("IP type mismatch")
 					}
-					return DiffIP(ip, ipb), info, true
+					return NumberValue(DiffIPBig(ip, ipb)), info, true
 				}
 				return info.Error("string argument for MINUS must be an IP address")
 			}
@@ -58,7 +61,7 @@ func (e SubtractionExpr) Evaluate(binding Binding, locally bool) (interface{}, E
 		}
 		return info.Error("string argument for MINUS must be an IP address")
 	}
-	return info.Error("first argument of MINUS must be IP address or integer")
+	return info.Error("first argument of MINUS must be IP address or number")
 }
 
 func (e SubtractionExpr) String() string {
@@ -1,7 +1,6 @@
 package dynaml
 
 import (
-	"io/ioutil"
 	"os"
 )
 
@@ -34,7 +33,7 @@ func func_tempfile(arguments []interface{}, binding Binding) (interface{}, Evalu
 		return info.Error("cannot create temporary file: %s", err)
 	}
 
-	err = ioutil.WriteFile(name, []byte(data), os.FileMode(permissions))
+	err = fileSystem(binding).WriteFile(name, []byte(data), os.FileMode(permissions))
 	if err != nil {
 		return info.Error("cannot write file: %s", err)
 	}
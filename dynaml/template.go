@@ -94,13 +94,13 @@ func node_copy(node yaml.Node) yaml.Node {
 		for i, v := range val {
 			list[i] = node_copy(v)
 		}
-		return yaml.NewNode(list, node.SourceName())
+		return yaml.PositionedNode(list, node.SourceName(), node)
 	case map[string]yaml.Node:
 		m := make(map[string]yaml.Node)
 		for k, v := range val {
 			m[k] = node_copy(v)
 		}
-		return yaml.NewNode(m, node.SourceName())
+		return yaml.PositionedNode(m, node.SourceName(), node)
 	}
-	return yaml.NewNode(node.Value(), node.SourceName())
+	return yaml.PositionedNode(node.Value(), node.SourceName(), node)
 }
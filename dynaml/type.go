@@ -29,6 +29,8 @@ func ExpressionType(elem interface{}) string {
 		return "string"
 	case int64:
 		return "int"
+	case float64:
+		return "float"
 	case bool:
 		return "bool"
 	case []yaml.Node:
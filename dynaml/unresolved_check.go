@@ -45,7 +45,7 @@ func (e UnresolvedNodes) Issue(msgfmt string, args ...interface{}) (result yaml.
 		message := fmt.Sprintf(
 			format,
 			node.Value(),
-			node.SourceName(),
+			node.SourceLocation(),
 			strings.Join(node.Context, "."),
 			strings.Join(node.Path, "."),
 			msg,
@@ -95,7 +95,7 @@ func (e UnresolvedNodes) Error() string {
 			format,
 			message,
 			val,
-			node.SourceName(),
+			node.SourceLocation(),
 			strings.Join(node.Context, "."),
 			strings.Join(node.Path, "."),
 			msg,
@@ -106,17 +106,33 @@ func (e UnresolvedNodes) Error() string {
 	return message
 }
 
-func tag(node yaml.Node) string {
-	tag := " "
+const (
+	CLASS_LOCAL     = "local"
+	CLASS_CYCLE     = "cycle"
+	CLASS_DEPENDENT = "dependent"
+)
+
+// Classification returns the error classification of an unresolved
+// node, which is denoted by the tag used in the error report.
+func Classification(node yaml.Node) string {
+	if node.HasError() {
+		return CLASS_LOCAL
+	}
 	if !node.Failed() {
-		tag = "@"
-	} else {
-		tag = "-"
+		return CLASS_CYCLE
 	}
-	if node.HasError() {
-		tag = "*"
+	return CLASS_DEPENDENT
+}
+
+func tag(node yaml.Node) string {
+	switch Classification(node) {
+	case CLASS_LOCAL:
+		return "*"
+	case CLASS_CYCLE:
+		return "@"
+	default:
+		return "-"
 	}
-	return tag
 }
 
 func nestedIssues(issue yaml.Issue) string {
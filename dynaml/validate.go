@@ -396,6 +396,14 @@ func handleStringType(value interface{}, op string, binding Binding, args ...yam
 		}
 		ip := net.ParseIP(s)
 		return SimpleValidatorResult(ip != nil, "is ip address", "is no ip address: %s", s)
+	case "ipv4", "ipv6":
+		s, err := StringValue(op, value)
+		if err != nil {
+			return ValidatorErrorf("%s: %s", op, err)
+		}
+		ip := net.ParseIP(s)
+		kind := "IPv" + op[3:]
+		return SimpleValidatorResult(ip != nil && (ip.To4() != nil) == (op == "ipv4"), "is "+kind+" address", "is no "+kind+" address: %s", s)
 	case "cidr":
 		s, err := StringValue(op, value)
 		if err != nil {
@@ -403,6 +411,17 @@ func handleStringType(value interface{}, op string, binding Binding, args ...yam
 		}
 		_, _, err = net.ParseCIDR(s)
 		return SimpleValidatorResult(err == nil, "is CIDR", "is no CIDR: %s", err)
+	case "cidrv4", "cidrv6":
+		s, err := StringValue(op, value)
+		if err != nil {
+			return ValidatorErrorf("%s: %s", op, err)
+		}
+		kind := "IPv" + op[5:]
+		_, cidr, err := net.ParseCIDR(s)
+		if err == nil && (cidr.IP.To4() != nil) != (op == "cidrv4") {
+			err = fmt.Errorf("%s is no %s network", s, kind)
+		}
+		return SimpleValidatorResult(err == nil, "is "+kind+" CIDR", "is no "+kind+" CIDR: %s", err)
 	default:
 		v := validators[op]
 		if v != nil {
@@ -3,7 +3,6 @@ package dynaml
 import (
 	"encoding/base64"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
@@ -39,7 +38,11 @@ func func_write(arguments []interface{}, binding Binding) (interface{}, Evaluati
 
 	file, raw, data, _ := getData(file, binary, 1, arguments[1], true)
 
-	err = ioutil.WriteFile(file, data, os.FileMode(permissions))
+	fs := fileSystem(binding)
+	if err := policy(binding).CheckFile(fs, file); err != nil {
+		return info.Error("write: %s", err)
+	}
+	err = fs.WriteFile(file, data, os.FileMode(permissions))
 	if err != nil {
 		return info.Error("cannot write file: %s", err)
 	}
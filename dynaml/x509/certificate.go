@@ -5,7 +5,6 @@ import (
 	"bytes"
 	"crypto/rand"
 	"crypto/x509"
-	"crypto/x509/pkix"
 	"encoding/pem"
 	"github.com/mandelsoft/spiff/yaml"
 	"math/big"
@@ -24,14 +23,26 @@ func init() {
 //  one map argument with fields
 //   usage:        []string
 //   organization: []string
+//   organizationalUnit: []string (optional)
 //   country: 	   []string (optional)
+//   locality:     []string (optional)
+//   province:     []string (optional)
 //   commonName:   string   (optional)
 //   validFrom :   string/date   (optional)
 //   validity :    int    (hours, optional)
 //   isCA:         boolean  (optional)
+//   maxPathLen:   int      (optional)
 //   hosts:        []string (optional)
+//   email:        []string (optional)
+//   uris:         []string (optional)
+//   serialNumber: int/string (optional)
+//   subjectKeyId: string   (hex, optional)
+//   authorityKeyId: string (hex, optional)
+//   nameConstraints: map   (optional)
+//   crlDistributionPoints: []string (optional)
 //   privateKey:   string
 //   publicKey:    string
+//   csr:          string   (instead of privateKey/publicKey)
 //
 //   caCert:       string   (optional)
 //   caPrivateKey: string   (optional)
@@ -54,36 +65,55 @@ func func_x509cert(arguments []interface{}, binding Binding) (interface{}, Evalu
 		return info.Error(err)
 	}
 
-	orgs, err := getStringListField(fields, "organization")
+	subject, err := getSubject(fields)
 	if err != nil {
 		return info.Error(err)
 	}
 
-	cn, err := getDefaultedStringField(fields, "commonName", "")
+	usages, err := getStringListField(fields, "usage")
 	if err != nil {
 		return info.Error(err)
 	}
 
-	countries, err := getDefaultedStringListField(fields, "country", nil)
+	validity, err := getDefaultedIntField(fields, "validity", 24*365)
 	if err != nil {
 		return info.Error(err)
 	}
 
-	usages, err := getStringListField(fields, "usage")
+	hosts, err := getDefaultedStringListField(fields, "hosts", nil)
 	if err != nil {
 		return info.Error(err)
 	}
 
-	validity, err := getDefaultedIntField(fields, "validity", 24*365)
+	emails, err := getDefaultedStringListField(fields, "email", nil)
 	if err != nil {
 		return info.Error(err)
 	}
 
-	hosts, err := getDefaultedStringListField(fields, "hosts", nil)
+	uris, err := getURIs(fields)
 	if err != nil {
 		return info.Error(err)
 	}
 
+	csrPEM, err := getDefaultedStringField(fields, "csr", "")
+	if err != nil {
+		return info.Error(err)
+	}
+	var csr *x509.CertificateRequest
+	if csrPEM != "" {
+		csr, err = ParseCertificateRequest(csrPEM)
+		if err != nil {
+			return info.Error("invalid certificate request: %s", err)
+		}
+		defaultSubject(&subject, csr.Subject)
+		if emails == nil {
+			emails = csr.EmailAddresses
+		}
+		if uris == nil {
+			uris = csr.URIs
+		}
+	}
+
 	privKey, err := getDefaultedStringField(fields, "privateKey", "")
 	if err != nil {
 		return info.Error(err)
@@ -108,6 +138,13 @@ func func_x509cert(arguments []interface{}, binding Binding) (interface{}, Evalu
 		}
 	}
 
+	if csr != nil {
+		if pub != nil || priv != nil {
+			return info.Error("'csr' cannot be combined with 'publicKey' or 'privateKey'")
+		}
+		pub = csr.PublicKey
+	}
+
 	if pub == nil {
 		if priv == nil {
 			return info.Error("one of 'publicKey' or 'privateKey' must be given")
@@ -144,6 +181,9 @@ func func_x509cert(arguments []interface{}, binding Binding) (interface{}, Evalu
 			return info.Error("private key for ca required")
 		}
 	}
+	if csr != nil && ca == nil {
+		return info.Error("ca certificate required to sign certificate request")
+	}
 	if capriv == nil {
 		return info.Error("private key for self-signed certificate required")
 	}
@@ -171,20 +211,22 @@ func func_x509cert(arguments []interface{}, binding Binding) (interface{}, Evalu
 	}
 
 	template := &x509.Certificate{
-		SerialNumber: serialNumber,
-		Subject: pkix.Name{
-			Organization: orgs,
-			CommonName:   cn,
-			Country:      countries,
-		},
-		NotBefore: notBefore,
-		NotAfter:  notAfter,
+		SerialNumber:   serialNumber,
+		Subject:        subject,
+		EmailAddresses: emails,
+		URIs:           uris,
+		NotBefore:      notBefore,
+		NotAfter:       notAfter,
 
 		KeyUsage:              0,
 		ExtKeyUsage:           []x509.ExtKeyUsage{},
 		BasicConstraintsValid: true,
 	}
 
+	if err := addExtensions(template, fields); err != nil {
+		return info.Error(err)
+	}
+
 	if ca == nil {
 		ca = template
 	}
@@ -197,6 +239,10 @@ func func_x509cert(arguments []interface{}, binding Binding) (interface{}, Evalu
 		k.AddTo(template)
 	}
 
+	if csr != nil && hosts == nil {
+		template.DNSNames = csr.DNSNames
+		template.IPAddresses = csr.IPAddresses
+	}
 	for _, h := range hosts {
 		if ip := net.ParseIP(h); ip != nil {
 			template.IPAddresses = append(template.IPAddresses, ip)
@@ -0,0 +1,105 @@
+package x509
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+
+	. "github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+const F_CSR = "x509csr"
+
+func init() {
+	RegisterFunction(F_CSR, func_x509csr)
+}
+
+//  one map argument with fields
+//   organization: []string (optional)
+//   organizationalUnit: []string (optional)
+//   country: 	   []string (optional)
+//   locality:     []string (optional)
+//   province:     []string (optional)
+//   commonName:   string   (optional)
+//   hosts:        []string (optional)
+//   email:        []string (optional)
+//   uris:         []string (optional)
+//   privateKey:   string
+//
+
+func func_x509csr(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	var err error
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("invalid argument count for %s(<map>)", F_CSR)
+	}
+	fields, ok := arguments[0].(map[string]yaml.Node)
+	if !ok {
+		return info.Error("argument for %s must be a map (found %s)", F_CSR, ExpressionType(arguments[0]))
+	}
+
+	subject, err := getSubject(fields)
+	if err != nil {
+		return info.Error(err)
+	}
+
+	hosts, err := getDefaultedStringListField(fields, "hosts", nil)
+	if err != nil {
+		return info.Error(err)
+	}
+
+	emails, err := getDefaultedStringListField(fields, "email", nil)
+	if err != nil {
+		return info.Error(err)
+	}
+
+	uris, err := getURIs(fields)
+	if err != nil {
+		return info.Error(err)
+	}
+
+	privKey, err := getDefaultedStringField(fields, "privateKey", "")
+	if err != nil {
+		return info.Error(err)
+	}
+	if privKey == "" {
+		return info.Error("field 'privateKey' is required")
+	}
+	priv, err := ParsePrivateKey(privKey)
+	if err != nil {
+		return info.Error(err)
+	}
+
+	template := &x509.CertificateRequest{
+		Subject:        subject,
+		EmailAddresses: emails,
+		URIs:           uris,
+	}
+
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	derBytes, err := x509.CreateCertificateRequest(rand.Reader, template, priv)
+	if err != nil {
+		return info.Error("Failed to create certificate request: %s", err)
+	}
+
+	var b bytes.Buffer
+	writer := bufio.NewWriter(&b)
+
+	if err := pem.Encode(writer, &pem.Block{Type: "CERTIFICATE REQUEST", Bytes: derBytes}); err != nil {
+		return info.Error("failed to write certificate request pem block: %s", err)
+	}
+	writer.Flush()
+	return b.String(), info, true
+}
@@ -0,0 +1,58 @@
+package x509
+
+import (
+	"time"
+
+	. "github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+const F_Expires = "x509expires"
+
+func init() {
+	RegisterFunction(F_Expires, func_x509expires)
+}
+
+// one or two arguments
+//  - certificate pem or map provided by x509parsecert
+//  - days (int, optional)
+
+func func_x509expires(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) < 1 || len(arguments) > 2 {
+		return info.Error("invalid argument count for %s(<cert>[, <days>])", F_Expires)
+	}
+
+	days := int64(0)
+	if len(arguments) == 2 {
+		var ok bool
+		days, ok = arguments[1].(int64)
+		if !ok {
+			return info.Error("days argument for %s must be an integer", F_Expires)
+		}
+	}
+
+	var notAfter time.Time
+	switch v := arguments[0].(type) {
+	case string:
+		cert, err := ParseCertificate(v)
+		if err != nil {
+			return info.Error("argument for %s must be a certificate in pem format: %s", F_Expires, err)
+		}
+		notAfter = cert.NotAfter
+	case map[string]yaml.Node:
+		until, err := getDefaultedStringField(v, "validUntil", "")
+		if err != nil || until == "" {
+			return info.Error("certificate map for %s requires field 'validUntil'", F_Expires)
+		}
+		notAfter, err = time.Parse("Jan 2 15:04:05 2006", until)
+		if err != nil {
+			return info.Error("invalid validUntil field for %s: %s", F_Expires, err)
+		}
+	default:
+		return info.Error("argument for %s must be a certificate or a parsed certificate map", F_Expires)
+	}
+
+	return time.Now().Add(time.Duration(days) * 24 * time.Hour).After(notAfter), info, true
+}
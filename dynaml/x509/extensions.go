@@ -0,0 +1,270 @@
+package x509
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"strings"
+
+	. "github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+// getSubject provides the subject name described by the spec fields
+func getSubject(fields map[string]yaml.Node) (pkix.Name, error) {
+	var err error
+	subject := pkix.Name{}
+
+	subject.CommonName, err = getDefaultedStringField(fields, "commonName", "")
+	if err != nil {
+		return subject, err
+	}
+	for name, field := range map[string]*[]string{
+		"organization":       &subject.Organization,
+		"organizationalUnit": &subject.OrganizationalUnit,
+		"country":            &subject.Country,
+		"locality":           &subject.Locality,
+		"province":           &subject.Province,
+	} {
+		*field, err = getDefaultedStringListField(fields, name, nil)
+		if err != nil {
+			return subject, err
+		}
+	}
+	return subject, nil
+}
+
+// defaultSubject fills the empty fields of a subject with the
+// values of the given default
+func defaultSubject(subject *pkix.Name, def pkix.Name) {
+	if subject.CommonName == "" {
+		subject.CommonName = def.CommonName
+	}
+	if subject.Organization == nil {
+		subject.Organization = def.Organization
+	}
+	if subject.OrganizationalUnit == nil {
+		subject.OrganizationalUnit = def.OrganizationalUnit
+	}
+	if subject.Country == nil {
+		subject.Country = def.Country
+	}
+	if subject.Locality == nil {
+		subject.Locality = def.Locality
+	}
+	if subject.Province == nil {
+		subject.Province = def.Province
+	}
+}
+
+// getURIs provides the URI SANs described by the spec fields
+func getURIs(fields map[string]yaml.Node) ([]*url.URL, error) {
+	list, err := getDefaultedStringListField(fields, "uris", nil)
+	if err != nil {
+		return nil, err
+	}
+	var uris []*url.URL
+	for _, u := range list {
+		uri, err := url.Parse(u)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uri %q: %s", u, err)
+		}
+		uris = append(uris, uri)
+	}
+	return uris, nil
+}
+
+// getKeyId parses a hex encoded key identifier. The bytes
+// may optionally be separated by colons.
+func getKeyId(fields map[string]yaml.Node, name string) ([]byte, error) {
+	s, err := getDefaultedStringField(fields, name, "")
+	if err != nil || s == "" {
+		return nil, err
+	}
+	id, err := hex.DecodeString(strings.Replace(s, ":", "", -1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key id for %q: %s", name, err)
+	}
+	return id, nil
+}
+
+// addExtensions adds the optional extensions described
+// by the spec fields to a certificate template
+func addExtensions(template *x509.Certificate, fields map[string]yaml.Node) error {
+	var err error
+
+	switch v := getField(fields, "serialNumber").(type) {
+	case nil:
+	case int64:
+		template.SerialNumber = big.NewInt(v)
+	case string:
+		serial, ok := new(big.Int).SetString(v, 0)
+		if !ok {
+			return fmt.Errorf("invalid serial number %q", v)
+		}
+		template.SerialNumber = serial
+	default:
+		return fmt.Errorf("invalid type for %q", "serialNumber")
+	}
+
+	if getField(fields, "maxPathLen") != nil {
+		pathLen, err := getDefaultedIntField(fields, "maxPathLen", -1)
+		if err != nil {
+			return err
+		}
+		template.MaxPathLen = int(pathLen)
+		template.MaxPathLenZero = pathLen == 0
+	}
+
+	template.SubjectKeyId, err = getKeyId(fields, "subjectKeyId")
+	if err != nil {
+		return err
+	}
+	template.AuthorityKeyId, err = getKeyId(fields, "authorityKeyId")
+	if err != nil {
+		return err
+	}
+
+	template.CRLDistributionPoints, err = getDefaultedStringListField(fields, "crlDistributionPoints", nil)
+	if err != nil {
+		return err
+	}
+
+	switch v := getField(fields, "nameConstraints").(type) {
+	case nil:
+	case map[string]yaml.Node:
+		return addNameConstraints(template, v)
+	default:
+		return fmt.Errorf("invalid type for %q", "nameConstraints")
+	}
+	return nil
+}
+
+func addNameConstraints(template *x509.Certificate, fields map[string]yaml.Node) error {
+	var err error
+
+	template.PermittedDNSDomainsCritical, err = getDefaultedBoolField(fields, "critical", false)
+	if err != nil {
+		return err
+	}
+	for name, field := range map[string]*[]string{
+		"permittedDNSDomains":     &template.PermittedDNSDomains,
+		"excludedDNSDomains":      &template.ExcludedDNSDomains,
+		"permittedEmailAddresses": &template.PermittedEmailAddresses,
+		"excludedEmailAddresses":  &template.ExcludedEmailAddresses,
+		"permittedURIDomains":     &template.PermittedURIDomains,
+		"excludedURIDomains":      &template.ExcludedURIDomains,
+	} {
+		*field, err = getDefaultedStringListField(fields, name, nil)
+		if err != nil {
+			return err
+		}
+	}
+	for name, field := range map[string]*[]*net.IPNet{
+		"permittedIPRanges": &template.PermittedIPRanges,
+		"excludedIPRanges":  &template.ExcludedIPRanges,
+	} {
+		list, err := getDefaultedStringListField(fields, name, nil)
+		if err != nil {
+			return err
+		}
+		for _, c := range list {
+			_, cidr, err := net.ParseCIDR(c)
+			if err != nil {
+				return fmt.Errorf("invalid ip range in %q: %s", name, err)
+			}
+			*field = append(*field, cidr)
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// addSubjectFields adds the fields for a subject name to a result map
+func addSubjectFields(result map[string]yaml.Node, subject pkix.Name, binding Binding) {
+	if subject.CommonName != "" {
+		result["commonName"] = NewNode(subject.CommonName, binding)
+	}
+	for name, field := range map[string][]string{
+		"organization":       subject.Organization,
+		"organizationalUnit": subject.OrganizationalUnit,
+		"country":            subject.Country,
+		"locality":           subject.Locality,
+		"province":           subject.Province,
+	} {
+		if field != nil {
+			result[name] = NodeStringList(field, binding)
+		}
+	}
+}
+
+// addExtensionFields adds the fields for the optional
+// extensions of a certificate to a result map
+func addExtensionFields(result map[string]yaml.Node, cert *x509.Certificate, binding Binding) {
+	result["serialNumber"] = NewNode(cert.SerialNumber.String(), binding)
+
+	if cert.EmailAddresses != nil {
+		result["email"] = NodeStringList(cert.EmailAddresses, binding)
+	}
+	if cert.URIs != nil {
+		result["uris"] = NodeURIList(cert.URIs, binding)
+	}
+	if cert.MaxPathLen > 0 || cert.MaxPathLenZero {
+		result["maxPathLen"] = NewNode(int64(cert.MaxPathLen), binding)
+	}
+	if cert.SubjectKeyId != nil {
+		result["subjectKeyId"] = NewNode(hex.EncodeToString(cert.SubjectKeyId), binding)
+	}
+	if cert.AuthorityKeyId != nil {
+		result["authorityKeyId"] = NewNode(hex.EncodeToString(cert.AuthorityKeyId), binding)
+	}
+	if cert.CRLDistributionPoints != nil {
+		result["crlDistributionPoints"] = NodeStringList(cert.CRLDistributionPoints, binding)
+	}
+
+	constraints := map[string]yaml.Node{}
+	for name, field := range map[string][]string{
+		"permittedDNSDomains":     cert.PermittedDNSDomains,
+		"excludedDNSDomains":      cert.ExcludedDNSDomains,
+		"permittedEmailAddresses": cert.PermittedEmailAddresses,
+		"excludedEmailAddresses":  cert.ExcludedEmailAddresses,
+		"permittedURIDomains":     cert.PermittedURIDomains,
+		"excludedURIDomains":      cert.ExcludedURIDomains,
+	} {
+		if len(field) > 0 {
+			constraints[name] = NodeStringList(field, binding)
+		}
+	}
+	for name, field := range map[string][]*net.IPNet{
+		"permittedIPRanges": cert.PermittedIPRanges,
+		"excludedIPRanges":  cert.ExcludedIPRanges,
+	} {
+		if len(field) > 0 {
+			ranges := make([]string, len(field))
+			for i, r := range field {
+				ranges[i] = r.String()
+			}
+			constraints[name] = NodeStringList(ranges, binding)
+		}
+	}
+	if len(constraints) > 0 {
+		if cert.PermittedDNSDomainsCritical {
+			constraints["critical"] = NewNode(true, binding)
+		}
+		result["nameConstraints"] = NewNode(constraints, binding)
+	}
+}
+
+func NodeURIList(list []*url.URL, binding Binding) yaml.Node {
+	nodelist := make([]yaml.Node, len(list))
+
+	for i, u := range list {
+		nodelist[i] = NewNode(u.String(), binding)
+	}
+	return NewNode(nodelist, binding)
+}
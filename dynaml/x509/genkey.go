@@ -4,11 +4,13 @@ import (
 	"bufio"
 	"bytes"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"encoding/pem"
 	"strconv"
+	"strings"
 
 	. "github.com/mandelsoft/spiff/dynaml"
 )
@@ -19,9 +21,11 @@ func init() {
 	RegisterFunction(F_GenKey, func_x509genkey)
 }
 
-// one optional argument
+// two optional arguments
 //  - either rsa bit size (int)
 //  - or ecdsaCurve (string)
+//  - or ed25519 (string)
+//  - format (string, pkcs8 for PKCS#8 encoding)
 
 func func_x509genkey(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
 	var err error
@@ -30,8 +34,23 @@ func func_x509genkey(arguments []interface{}, binding Binding) (interface{}, Eva
 	ecdsaCurve := ""
 	rsaBits := int64(2048)
 
+	if len(arguments) > 2 {
+		return info.Error("invalid argument count for %s([<bitsize>|<ecdsaCurve>|ed25519[, <format>]])", F_GenKey)
+	}
+
+	pkcs8 := false
 	if len(arguments) > 1 {
-		return info.Error("invalid argument count for %s([<bitsize>|<ecdsaCurve>])", F_GenKey)
+		format, ok := arguments[1].(string)
+		if !ok {
+			return info.Error("format argument for %s must be a string", F_GenKey)
+		}
+		switch strings.ToLower(format) {
+		case "pkcs8":
+			pkcs8 = true
+		case "", "pem":
+		default:
+			return info.Error("invalid key format for %s: %s", F_GenKey, format)
+		}
 	}
 
 	if len(arguments) > 0 {
@@ -60,6 +79,8 @@ func func_x509genkey(arguments []interface{}, binding Binding) (interface{}, Eva
 		priv, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
 	case "P521":
 		priv, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case "ed25519", "Ed25519":
+		_, priv, err = ed25519.GenerateKey(rand.Reader)
 	default:
 		return info.Error("Unrecognized elliptic curve: %q", ecdsaCurve)
 	}
@@ -70,7 +91,11 @@ func func_x509genkey(arguments []interface{}, binding Binding) (interface{}, Eva
 	var b bytes.Buffer
 	writer := bufio.NewWriter(&b)
 
-	if err := pem.Encode(writer, pemBlockForKey(priv)); err != nil {
+	block := pemBlockForKey(priv)
+	if pkcs8 {
+		block = pkcs8PemBlockForKey(priv)
+	}
+	if err := pem.Encode(writer, block); err != nil {
 		return info.Error("failed to write key pem block: %s", err)
 	}
 	writer.Flush()
@@ -0,0 +1,190 @@
+package x509
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"time"
+	"unicode/utf16"
+
+	. "github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+const F_JKS = "x509jks"
+
+func init() {
+	RegisterFunction(F_JKS, func_x509jks)
+}
+
+//  one map argument with fields
+//   cert:         string   (optional)
+//   privateKey:   string   (optional)
+//   caCerts:      []string (optional)
+//   password:     string
+//   alias:        string   (optional)
+//
+
+func func_x509jks(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("invalid argument count for %s(<map>)", F_JKS)
+	}
+	fields, ok := arguments[0].(map[string]yaml.Node)
+	if !ok {
+		return info.Error("argument for %s must be a map (found %s)", F_JKS, ExpressionType(arguments[0]))
+	}
+
+	store, err := getKeyStoreFields(fields)
+	if err != nil {
+		return info.Error(err)
+	}
+	if store.password == "" {
+		return info.Error("field 'password' is required")
+	}
+	alias, err := getDefaultedStringField(fields, "alias", "key")
+	if err != nil {
+		return info.Error(err)
+	}
+	if store.cert == nil && len(store.caCerts) == 0 {
+		return info.Error("at least one of 'cert' or 'caCerts' must be given")
+	}
+
+	data, err := encodeJKS(store, alias)
+	if err != nil {
+		return info.Error("failed to create java keystore: %s", err)
+	}
+	return Base64Encode(data, 60), info, true
+}
+
+const (
+	jksMagic          = 0xfeedfeed
+	jksVersion        = 2
+	jksPrivateKeyTag  = 1
+	jksTrustedCertTag = 2
+)
+
+// oidJKSKeyProtector is the algorithm used by the sun
+// key protector to encrypt private keys in a keystore
+var oidJKSKeyProtector = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 17, 1, 1}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// encodeJKS creates a java keystore. With a private key, the key
+// is stored together with the certificate chain under the given alias.
+// Otherwise the certificates are stored as trusted certificates to be
+// used as truststore.
+func encodeJKS(store *keyStore, alias string) ([]byte, error) {
+	password := utf16BE(store.password)
+	buf := &bytes.Buffer{}
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+
+	type trusted struct {
+		alias string
+		cert  *x509.Certificate
+	}
+	entries := []trusted{}
+	if store.key == nil && store.cert != nil {
+		entries = append(entries, trusted{alias, store.cert})
+	}
+	for i, c := range store.caCerts {
+		entries = append(entries, trusted{fmt.Sprintf("ca%d", i), c})
+	}
+
+	count := len(entries)
+	if store.key != nil {
+		count++
+	}
+	binary.Write(buf, binary.BigEndian, uint32(jksMagic))
+	binary.Write(buf, binary.BigEndian, uint32(jksVersion))
+	binary.Write(buf, binary.BigEndian, uint32(count))
+
+	if store.key != nil {
+		protected, err := protectJKSKey(store.key, password)
+		if err != nil {
+			return nil, err
+		}
+		binary.Write(buf, binary.BigEndian, uint32(jksPrivateKeyTag))
+		writeJKSString(buf, alias)
+		binary.Write(buf, binary.BigEndian, now)
+		binary.Write(buf, binary.BigEndian, uint32(len(protected)))
+		buf.Write(protected)
+		chain := append([]*x509.Certificate{store.cert}, store.caCerts...)
+		binary.Write(buf, binary.BigEndian, uint32(len(chain)))
+		for _, c := range chain {
+			writeJKSCertificate(buf, c)
+		}
+	}
+	for _, e := range entries {
+		binary.Write(buf, binary.BigEndian, uint32(jksTrustedCertTag))
+		writeJKSString(buf, e.alias)
+		binary.Write(buf, binary.BigEndian, now)
+		writeJKSCertificate(buf, e.cert)
+	}
+
+	digest := sha1.New()
+	digest.Write(password)
+	digest.Write([]byte("Mighty Aphrodite"))
+	digest.Write(buf.Bytes())
+	buf.Write(digest.Sum(nil))
+	return buf.Bytes(), nil
+}
+
+func writeJKSString(buf *bytes.Buffer, s string) {
+	binary.Write(buf, binary.BigEndian, uint16(len(s)))
+	buf.WriteString(s)
+}
+
+func writeJKSCertificate(buf *bytes.Buffer, cert *x509.Certificate) {
+	writeJKSString(buf, "X.509")
+	binary.Write(buf, binary.BigEndian, uint32(len(cert.Raw)))
+	buf.Write(cert.Raw)
+}
+
+// protectJKSKey encrypts a private key with the proprietary
+// algorithm of the sun key protector
+func protectJKSKey(key interface{}, password []byte) ([]byte, error) {
+	plain, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, sha1.Size)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	encrypted := make([]byte, len(plain))
+	digest := salt
+	for i := 0; i < len(plain); i += sha1.Size {
+		h := sha1.Sum(append(append([]byte{}, password...), digest...))
+		digest = h[:]
+		for j := 0; j < sha1.Size && i+j < len(plain); j++ {
+			encrypted[i+j] = plain[i+j] ^ digest[j]
+		}
+	}
+	check := sha1.Sum(append(append([]byte{}, password...), plain...))
+
+	data := append(append(append([]byte{}, salt...), encrypted...), check[:]...)
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidJKSKeyProtector, Parameters: asn1.NullRawValue},
+		EncryptedData: data,
+	})
+}
+
+// utf16BE encodes a password as used by the java keystore
+func utf16BE(s string) []byte {
+	result := []byte{}
+	for _, c := range utf16.Encode([]rune(s)) {
+		result = append(result, byte(c>>8), byte(c))
+	}
+	return result
+}
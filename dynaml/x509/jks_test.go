@@ -0,0 +1,238 @@
+package x509
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+type jksEntry struct {
+	tag   uint32
+	alias string
+	date  time.Time
+	key   interface{}
+	certs []*x509.Certificate
+}
+
+// loadJKS reads a java keystore as done by the load method of
+// sun.security.provider.JavaKeyStore and recovers the private keys
+// like sun.security.provider.KeyProtector.
+func loadJKS(data []byte, password string) ([]jksEntry, error) {
+	if len(data) < sha1.Size {
+		return nil, fmt.Errorf("keystore too short")
+	}
+	passwd := []byte{}
+	for _, c := range password {
+		passwd = append(passwd, byte(c>>8), byte(c))
+	}
+	content, check := data[:len(data)-sha1.Size], data[len(data)-sha1.Size:]
+	digest := sha1.New()
+	digest.Write(passwd)
+	digest.Write([]byte("Mighty Aphrodite"))
+	digest.Write(content)
+	if !bytes.Equal(digest.Sum(nil), check) {
+		return nil, fmt.Errorf("keystore was tampered with, or password was incorrect")
+	}
+
+	r := bytes.NewReader(content)
+	var magic, version, count uint32
+	readJKS(r, &magic, &version, &count)
+	if magic != 0xfeedfeed || version != 2 {
+		return nil, fmt.Errorf("invalid keystore format")
+	}
+
+	entries := []jksEntry{}
+	for i := uint32(0); i < count; i++ {
+		var entry jksEntry
+		var date int64
+		readJKS(r, &entry.tag)
+		entry.alias = readJKSUTF(r)
+		readJKS(r, &date)
+		entry.date = time.Unix(0, date*int64(time.Millisecond))
+		switch entry.tag {
+		case 1:
+			var size, chain uint32
+			readJKS(r, &size)
+			protected := make([]byte, size)
+			io.ReadFull(r, protected)
+			key, err := recoverJKSKey(protected, passwd)
+			if err != nil {
+				return nil, err
+			}
+			entry.key = key
+			readJKS(r, &chain)
+			for j := uint32(0); j < chain; j++ {
+				cert, err := readJKSCertificate(r)
+				if err != nil {
+					return nil, err
+				}
+				entry.certs = append(entry.certs, cert)
+			}
+		case 2:
+			cert, err := readJKSCertificate(r)
+			if err != nil {
+				return nil, err
+			}
+			entry.certs = append(entry.certs, cert)
+		default:
+			return nil, fmt.Errorf("unrecognized keystore entry")
+		}
+		entries = append(entries, entry)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes in keystore", r.Len())
+	}
+	return entries, nil
+}
+
+func readJKS(r io.Reader, values ...interface{}) {
+	for _, v := range values {
+		binary.Read(r, binary.BigEndian, v)
+	}
+}
+
+func readJKSUTF(r io.Reader) string {
+	var size uint16
+	readJKS(r, &size)
+	s := make([]byte, size)
+	io.ReadFull(r, s)
+	return string(s)
+}
+
+func readJKSCertificate(r io.Reader) (*x509.Certificate, error) {
+	if t := readJKSUTF(r); t != "X.509" {
+		return nil, fmt.Errorf("unexpected certificate type %q", t)
+	}
+	var size uint32
+	readJKS(r, &size)
+	der := make([]byte, size)
+	io.ReadFull(r, der)
+	return x509.ParseCertificate(der)
+}
+
+func recoverJKSKey(protected, passwd []byte) (interface{}, error) {
+	var info struct {
+		Algorithm struct {
+			Algorithm  asn1.ObjectIdentifier
+			Parameters asn1.RawValue `asn1:"optional"`
+		}
+		EncryptedData []byte
+	}
+	if _, err := asn1.Unmarshal(protected, &info); err != nil {
+		return nil, err
+	}
+	if !info.Algorithm.Algorithm.Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 17, 1, 1}) {
+		return nil, fmt.Errorf("unsupported key protection algorithm %s", info.Algorithm.Algorithm)
+	}
+	data := info.EncryptedData
+	salt := data[:sha1.Size]
+	encrypted := data[sha1.Size : len(data)-sha1.Size]
+	check := data[len(data)-sha1.Size:]
+
+	xorKey := []byte{}
+	digest := salt
+	for len(xorKey) < len(encrypted) {
+		md := sha1.New()
+		md.Write(passwd)
+		md.Write(digest)
+		digest = md.Sum(nil)
+		xorKey = append(xorKey, digest...)
+	}
+	plain := make([]byte, len(encrypted))
+	for i := range encrypted {
+		plain[i] = encrypted[i] ^ xorKey[i]
+	}
+
+	md := sha1.New()
+	md.Write(passwd)
+	md.Write(plain)
+	if !bytes.Equal(md.Sum(nil), check) {
+		return nil, fmt.Errorf("cannot recover key")
+	}
+	return x509.ParsePKCS8PrivateKey(plain)
+}
+
+func createJKS(fields map[string]yaml.Node) []byte {
+	result, info, ok := func_x509jks([]interface{}{fields}, nil)
+	Expect(ok).To(BeTrue(), info.Issue.Issue)
+	data, err := base64.StdEncoding.DecodeString(strings.Replace(result.(string), "\n", "", -1))
+	Expect(err).NotTo(HaveOccurred())
+	return data
+}
+
+func mustParseCertificate(pem string) *x509.Certificate {
+	cert, err := ParseCertificate(pem)
+	Expect(err).NotTo(HaveOccurred())
+	return cert
+}
+
+var _ = Describe("jks", func() {
+	It("creates keystores with the key, the certificate chain and the trusted ca certificates", func() {
+		start := time.Now().Truncate(time.Millisecond)
+		data := createJKS(map[string]yaml.Node{
+			"cert":       NewNode(opensslEd25519Cert, nil),
+			"privateKey": NewNode(opensslEd25519Key, nil),
+			"caCerts":    NewNode(opensslEd25519CA, nil),
+			"password":   NewNode("changeit", nil),
+			"alias":      NewNode("server", nil),
+		})
+
+		entries, err := loadJKS(data, "changeit")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].tag).To(Equal(uint32(1)))
+		Expect(entries[0].alias).To(Equal("server"))
+		Expect(entries[0].date).To(BeTemporally(">=", start))
+		Expect(entries[0].date).To(BeTemporally("<=", time.Now()))
+
+		key, err := ParsePrivateKey(opensslEd25519Key)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries[0].key).To(Equal(key))
+		Expect(entries[0].certs).To(Equal([]*x509.Certificate{
+			mustParseCertificate(opensslEd25519Cert),
+			mustParseCertificate(opensslEd25519CA),
+		}))
+		Expect(entries[1].tag).To(Equal(uint32(2)))
+		Expect(entries[1].alias).To(Equal("ca0"))
+		Expect(entries[1].certs).To(Equal([]*x509.Certificate{mustParseCertificate(opensslEd25519CA)}))
+	})
+
+	It("creates truststores without private key", func() {
+		data := createJKS(map[string]yaml.Node{
+			"caCerts":  NewNode([]yaml.Node{NewNode(opensslEd25519CA, nil), NewNode(opensslECDSACert, nil)}, nil),
+			"password": NewNode("changeit", nil),
+		})
+
+		entries, err := loadJKS(data, "changeit")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		for i, cert := range []string{opensslEd25519CA, opensslECDSACert} {
+			Expect(entries[i].tag).To(Equal(uint32(2)))
+			Expect(entries[i].alias).To(Equal(fmt.Sprintf("ca%d", i)))
+			Expect(entries[i].certs).To(Equal([]*x509.Certificate{mustParseCertificate(cert)}))
+		}
+	})
+
+	It("protects the integrity with the password", func() {
+		data := createJKS(map[string]yaml.Node{
+			"cert":     NewNode(opensslECDSACert, nil),
+			"password": NewNode("changeit", nil),
+		})
+
+		_, err := loadJKS(data, "wrong")
+		Expect(err).To(MatchError("keystore was tampered with, or password was incorrect"))
+	})
+})
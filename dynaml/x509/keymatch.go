@@ -0,0 +1,58 @@
+package x509
+
+import (
+	"crypto"
+	"crypto/x509"
+
+	. "github.com/mandelsoft/spiff/dynaml"
+)
+
+const F_KeyMatch = "x509keymatch"
+
+func init() {
+	RegisterFunction(F_KeyMatch, func_x509keymatch)
+}
+
+// two arguments
+//  - certificate pem
+//  - private or public key pem
+
+func func_x509keymatch(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 2 {
+		return info.Error("invalid argument count for %s(<cert>, <key>)", F_KeyMatch)
+	}
+
+	str, ok := arguments[0].(string)
+	if !ok {
+		return info.Error("argument for %s must be a certificate in pem format", F_KeyMatch)
+	}
+	cert, err := ParseCertificate(str)
+	if err != nil {
+		return info.Error("argument for %s must be a certificate in pem format: %s", F_KeyMatch, err)
+	}
+
+	str, ok = arguments[1].(string)
+	if !ok {
+		return info.Error("key argument for %s must be a key in pem format", F_KeyMatch)
+	}
+	key, err := ParsePrivateKey(str)
+	if err != nil {
+		k, e := ParsePublicKey(str)
+		if e != nil {
+			return info.Error("key argument for %s must be a key in pem format: %s", F_KeyMatch, err)
+		}
+		key = k
+	}
+
+	return keyMatches(cert, key), info, true
+}
+
+// keyMatches checks whether a private or public key belongs to a certificate
+func keyMatches(cert *x509.Certificate, key interface{}) bool {
+	pub, ok := publicKey(key).(interface {
+		Equal(crypto.PublicKey) bool
+	})
+	return ok && pub.Equal(cert.PublicKey)
+}
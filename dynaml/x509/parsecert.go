@@ -34,15 +34,7 @@ func func_x509parsecert(arguments []interface{}, binding Binding) (interface{},
 	result := map[string]yaml.Node{}
 
 	result["isCA"] = NewNode(cert.IsCA, binding)
-	if cert.Subject.CommonName != "" {
-		result["commonName"] = NewNode(cert.Subject.CommonName, binding)
-	}
-	if cert.Subject.Country != nil {
-		result["country"] = NodeStringList(cert.Subject.Country, binding)
-	}
-	if cert.Subject.Organization != nil {
-		result["organization"] = NodeStringList(cert.Subject.Organization, binding)
-	}
+	addSubjectFields(result, cert.Subject, binding)
 
 	if cert.DNSNames != nil {
 		result["dnsNames"] = NodeStringList(cert.DNSNames, binding)
@@ -56,6 +48,8 @@ func func_x509parsecert(arguments []interface{}, binding Binding) (interface{},
 				NodeStringList(cert.DNSNames, binding).Value().([]yaml.Node)...), binding)
 	}
 
+	addExtensionFields(result, cert, binding)
+
 	result["validFrom"] = NewNode(cert.NotBefore.Format("Jan 2 15:04:05 2006"), binding)
 	result["validUntil"] = NewNode(cert.NotAfter.Format("Jan 2 15:04:05 2006"), binding)
 	result["validity"] = NewNode(int64(cert.NotAfter.Sub(time.Now())/time.Hour), binding)
@@ -0,0 +1,61 @@
+package x509
+
+import (
+	. "github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+const F_ParseCSR = "x509parsecsr"
+
+func init() {
+	RegisterFunction(F_ParseCSR, func_x509parsecsr)
+}
+
+func func_x509parsecsr(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	var err error
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("invalid argument count for %s", F_ParseCSR)
+	}
+
+	pemstr, ok := arguments[0].(string)
+	if !ok {
+		return info.Error("argument for %s must be a certificate request in pem format", F_ParseCSR)
+	}
+
+	csr, err := ParseCertificateRequest(pemstr)
+	if err != nil {
+		return info.Error("argument for %s must be a certificate request in pem format: %s", F_ParseCSR, err)
+	}
+
+	result := map[string]yaml.Node{}
+
+	addSubjectFields(result, csr.Subject, binding)
+
+	if csr.DNSNames != nil {
+		result["dnsNames"] = NodeStringList(csr.DNSNames, binding)
+	}
+	if csr.IPAddresses != nil {
+		result["ipAddresses"] = NodeIPList(csr.IPAddresses, binding)
+	}
+	if csr.EmailAddresses != nil {
+		result["email"] = NodeStringList(csr.EmailAddresses, binding)
+	}
+	if csr.URIs != nil {
+		result["uris"] = NodeURIList(csr.URIs, binding)
+	}
+	if len(csr.DNSNames)+len(csr.IPAddresses) > 0 {
+		result["hosts"] = NewNode(
+			append(NodeIPList(csr.IPAddresses, binding).Value().([]yaml.Node),
+				NodeStringList(csr.DNSNames, binding).Value().([]yaml.Node)...), binding)
+	}
+
+	str, err := PublicKeyPEM(publicKey(csr.PublicKey))
+	if err != nil {
+		return info.Error("%s", err)
+	}
+	result["publicKey"] = NewNode(str, binding)
+
+	return result, info, true
+}
@@ -0,0 +1,190 @@
+package x509
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+
+	"software.sslmate.com/src/go-pkcs12"
+
+	. "github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+const F_PKCS12 = "x509pkcs12"
+const F_ParsePKCS12 = "x509parsepkcs12"
+
+func init() {
+	RegisterFunction(F_PKCS12, func_x509pkcs12)
+	RegisterFunction(F_ParsePKCS12, func_x509parsepkcs12)
+}
+
+//  one map argument with fields
+//   cert:         string
+//   privateKey:   string   (optional)
+//   caCerts:      []string (optional)
+//   password:     string   (optional)
+//
+
+func func_x509pkcs12(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("invalid argument count for %s(<map>)", F_PKCS12)
+	}
+	fields, ok := arguments[0].(map[string]yaml.Node)
+	if !ok {
+		return info.Error("argument for %s must be a map (found %s)", F_PKCS12, ExpressionType(arguments[0]))
+	}
+
+	bundle, err := getKeyStoreFields(fields)
+	if err != nil {
+		return info.Error(err)
+	}
+	if bundle.cert == nil {
+		return info.Error("field 'cert' is required")
+	}
+
+	data, err := encodePKCS12(bundle)
+	if err != nil {
+		return info.Error("failed to create pkcs12 bundle: %s", err)
+	}
+	return Base64Encode(data, 60), info, true
+}
+
+// two arguments
+//  - base64 encoded pkcs12 bundle
+//  - password (optional)
+
+func func_x509parsepkcs12(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) < 1 || len(arguments) > 2 {
+		return info.Error("invalid argument count for %s(<pkcs12>[, <password>])", F_ParsePKCS12)
+	}
+	str, ok := arguments[0].(string)
+	if !ok {
+		return info.Error("argument for %s must be a base64 encoded pkcs12 bundle", F_ParsePKCS12)
+	}
+	data, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return info.Error("argument for %s must be a base64 encoded pkcs12 bundle: %s", F_ParsePKCS12, err)
+	}
+	password := ""
+	if len(arguments) == 2 {
+		password, ok = arguments[1].(string)
+		if !ok {
+			return info.Error("password argument for %s must be a string", F_ParsePKCS12)
+		}
+	}
+
+	var certs []*x509.Certificate
+	key, cert, cas, err := pkcs12.DecodeChain(data, password)
+	if err == nil {
+		certs = append([]*x509.Certificate{cert}, cas...)
+	} else {
+		// bundles without private key are decoded as truststore
+		var terr error
+		if certs, terr = pkcs12.DecodeTrustStore(data, password); terr != nil {
+			return info.Error("invalid pkcs12 bundle: %s", err)
+		}
+	}
+
+	result := map[string]yaml.Node{}
+	if key != nil {
+		switch key.(type) {
+		case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		default:
+			return info.Error("unsupported private key type %T in pkcs12 bundle", key)
+		}
+		result["privateKey"] = NewNode(string(pem.EncodeToMemory(pemBlockForKey(key))), binding)
+	}
+
+	// the certificate for the private key is the cert, all other
+	// certificates are provided as ca certificates
+	caCerts := []yaml.Node{}
+	for _, cert := range certs {
+		block := &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}
+		if result["cert"] == nil && (key == nil || keyMatches(cert, key)) {
+			result["cert"] = NewNode(string(pem.EncodeToMemory(block)), binding)
+		} else {
+			caCerts = append(caCerts, NewNode(string(pem.EncodeToMemory(block)), binding))
+		}
+	}
+	result["caCerts"] = NewNode(caCerts, binding)
+	return result, info, true
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// keyStore describes the content of a pkcs12 bundle or java keystore
+type keyStore struct {
+	cert     *x509.Certificate
+	key      interface{}
+	caCerts  []*x509.Certificate
+	password string
+}
+
+func getKeyStoreFields(fields map[string]yaml.Node) (*keyStore, error) {
+	var err error
+	store := &keyStore{}
+
+	str, err := getDefaultedStringField(fields, "cert", "")
+	if err != nil {
+		return nil, err
+	}
+	if str != "" {
+		store.cert, err = ParseCertificate(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate: %s", err)
+		}
+	}
+
+	str, err = getDefaultedStringField(fields, "privateKey", "")
+	if err != nil {
+		return nil, err
+	}
+	if str != "" {
+		store.key, err = ParsePrivateKey(str)
+		if err != nil {
+			return nil, err
+		}
+		if store.cert == nil {
+			return nil, fmt.Errorf("private key requires field 'cert'")
+		}
+		if !keyMatches(store.cert, store.key) {
+			return nil, fmt.Errorf("private key does not match certificate")
+		}
+	}
+
+	cas, err := getDefaultedStringListField(fields, "caCerts", nil)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range cas {
+		cert, err := ParseCertificate(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ca certificate %d: %s", i, err)
+		}
+		store.caCerts = append(store.caCerts, cert)
+	}
+
+	store.password, err = getDefaultedStringField(fields, "password", "")
+	return store, err
+}
+
+// encodePKCS12 creates a password protected pkcs12 bundle. Certificates
+// and the private key are encrypted with pbeWithSHAAnd3-KeyTripleDES-CBC,
+// the integrity is protected by an HMAC-SHA1. Without private key the
+// certificates are stored as trusted certificates.
+func encodePKCS12(store *keyStore) ([]byte, error) {
+	if store.key == nil {
+		certs := append([]*x509.Certificate{store.cert}, store.caCerts...)
+		return pkcs12.LegacyDES.EncodeTrustStore(certs, store.password)
+	}
+	return pkcs12.LegacyDES.Encode(store.key, store.cert, store.caCerts, store.password)
+}
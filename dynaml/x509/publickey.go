@@ -3,11 +3,13 @@ package x509
 import (
 	"bufio"
 	"bytes"
+	"crypto/ed25519"
 	"encoding/pem"
 	"fmt"
 	. "github.com/mandelsoft/spiff/dynaml"
 	"strings"
 
+	xed25519 "golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -63,7 +65,7 @@ func func_x509publickey(arguments []interface{}, binding Binding) (interface{},
 		str, err = PublicKeyPEM(publicKey(key))
 	case "ssh":
 		var pk ssh.PublicKey
-		pk, err = ssh.NewPublicKey(publicKey(key))
+		pk, err = ssh.NewPublicKey(sshKey(publicKey(key)))
 		if err == nil {
 			str = string(ssh.MarshalAuthorizedKey(pk))
 		}
@@ -85,3 +87,11 @@ func PublicKeyPEM(key interface{}) (string, error) {
 	writer.Flush()
 	return b.String(), nil
 }
+
+// sshKey maps public keys to the types supported by the ssh package
+func sshKey(key interface{}) interface{} {
+	if k, ok := key.(ed25519.PublicKey); ok {
+		return xed25519.PublicKey(k)
+	}
+	return key
+}
@@ -3,6 +3,7 @@ package x509
 import (
 	"crypto/dsa"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -19,6 +20,17 @@ func privateKey(block *pem.Block) (interface{}, error) {
 		return x509.ParsePKCS1PrivateKey(x509Encoded)
 	case "EC PRIVATE KEY":
 		return x509.ParseECPrivateKey(x509Encoded)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(x509Encoded)
+		if err != nil {
+			return nil, err
+		}
+		switch key.(type) {
+		case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+			return key, nil
+		default:
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
 	default:
 		return nil, fmt.Errorf("invalid pem block type %q", block.Type)
 	}
@@ -30,11 +42,15 @@ func publicKey(priv interface{}) interface{} {
 		return k
 	case *ecdsa.PublicKey:
 		return k
+	case ed25519.PublicKey:
+		return k
 
 	case *rsa.PrivateKey:
 		return &k.PublicKey
 	case *ecdsa.PrivateKey:
 		return &k.PublicKey
+	case ed25519.PrivateKey:
+		return k.Public()
 	case *x509.Certificate:
 		return k.PublicKey
 	default:
@@ -53,12 +69,25 @@ func pemBlockForKey(priv interface{}) *pem.Block {
 			os.Exit(2)
 		}
 		return &pem.Block{Type: "EC PRIVATE KEY", Bytes: b}
+	case ed25519.PrivateKey:
+		return pkcs8PemBlockForKey(k)
 	default:
 		log.Fatal("invalid key")
 		return nil
 	}
 }
 
+// pkcs8PemBlockForKey encodes a private key of any supported
+// type as PKCS#8 PEM block
+func pkcs8PemBlockForKey(priv interface{}) *pem.Block {
+	b, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to marshal private key: %v", err)
+		os.Exit(2)
+	}
+	return &pem.Block{Type: "PRIVATE KEY", Bytes: b}
+}
+
 func pemBlockForPublicKey(priv interface{}) *pem.Block {
 	switch k := priv.(type) {
 	case *rsa.PublicKey:
@@ -69,6 +98,12 @@ func pemBlockForPublicKey(priv interface{}) *pem.Block {
 			return nil
 		}
 		return &pem.Block{Type: "ECDSA PUBLIC KEY", Bytes: b}
+	case ed25519.PublicKey:
+		b, err := x509.MarshalPKIXPublicKey(k)
+		if err != nil {
+			return nil
+		}
+		return &pem.Block{Type: "PUBLIC KEY", Bytes: b}
 	default:
 		return nil
 	}
@@ -94,6 +129,8 @@ func ParsePublicKey(data string) (interface{}, error) {
 		return pub, nil
 	case *ecdsa.PublicKey:
 		return pub, nil
+	case ed25519.PublicKey:
+		return pub, nil
 	default:
 		return nil, fmt.Errorf("unknown type of public key")
 	}
@@ -118,6 +155,24 @@ func ParseCertificate(data string) (*x509.Certificate, error) {
 	return x509.ParseCertificate(block.Bytes)
 }
 
+func ParseCertificateRequest(data string) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("invalid certificate request format (expected pem block)")
+	}
+	if block.Type != "CERTIFICATE REQUEST" && block.Type != "NEW CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("unexpected pem block type for certificate request: %q", block.Type)
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid certificate request signature: %s", err)
+	}
+	return csr, nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 type KeyUsage interface {
@@ -0,0 +1,109 @@
+package x509
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	. "github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+const F_Verify = "x509verify"
+
+func init() {
+	RegisterFunction(F_Verify, func_x509verify)
+}
+
+// two or three arguments
+//  - certificate pem
+//  - ca certificate(s) (string with pem blocks or list)
+//  - intermediate certificate(s) (optional, string with pem blocks or list)
+
+func func_x509verify(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) < 2 || len(arguments) > 3 {
+		return info.Error("invalid argument count for %s(<cert>, <ca>[, <intermediates>])", F_Verify)
+	}
+
+	str, ok := arguments[0].(string)
+	if !ok {
+		return info.Error("argument for %s must be a certificate in pem format", F_Verify)
+	}
+	cert, err := ParseCertificate(str)
+	if err != nil {
+		return info.Error("argument for %s must be a certificate in pem format: %s", F_Verify, err)
+	}
+
+	roots, err := certPool(arguments[1])
+	if err != nil {
+		return info.Error("invalid ca argument for %s: %s", F_Verify, err)
+	}
+	opts := x509.VerifyOptions{
+		Roots:       roots,
+		CurrentTime: time.Now(),
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	if len(arguments) == 3 {
+		opts.Intermediates, err = certPool(arguments[2])
+		if err != nil {
+			return info.Error("invalid intermediates argument for %s: %s", F_Verify, err)
+		}
+	}
+
+	_, err = cert.Verify(opts)
+	return err == nil, info, true
+}
+
+// certPool provides a pool for certificates given as list or
+// as string containing a sequence of pem blocks
+func certPool(arg interface{}) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	switch v := arg.(type) {
+	case string:
+		if err := addCertificates(pool, v); err != nil {
+			return nil, err
+		}
+	case []yaml.Node:
+		for i, e := range v {
+			str, ok := e.Value().(string)
+			if !ok {
+				return nil, fmt.Errorf("list entry %d must be a certificate in pem format", i)
+			}
+			if err := addCertificates(pool, str); err != nil {
+				return nil, fmt.Errorf("list entry %d: %s", i, err)
+			}
+		}
+	case nil:
+	default:
+		return nil, fmt.Errorf("certificate or list of certificates required")
+	}
+	return pool, nil
+}
+
+func addCertificates(pool *x509.CertPool, data string) error {
+	rest := []byte(data)
+	found := false
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("unexpected pem block type for certificate: %q", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return err
+		}
+		pool.AddCert(cert)
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("invalid certificate format (expected pem block)")
+	}
+	return nil
+}
@@ -59,6 +59,25 @@ func DiscardNonState(node yaml.Node) (yaml.Node, CleanupFunction) {
 	return nil, DiscardNonState
 }
 
+// Walk calls the given function for all nodes of a document
+// without modifying it.
+func Walk(node yaml.Node, f func(yaml.Node)) {
+	if node == nil {
+		return
+	}
+	f(node)
+	switch v := node.Value().(type) {
+	case []yaml.Node:
+		for _, e := range v {
+			Walk(e, f)
+		}
+	case map[string]yaml.Node:
+		for _, e := range v {
+			Walk(e, f)
+		}
+	}
+}
+
 type CleanupFunction func(yaml.Node) (yaml.Node, CleanupFunction)
 
 func Cleanup(node yaml.Node, test CleanupFunction) yaml.Node {
@@ -0,0 +1,112 @@
+package flow
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+// dependencyGraph describes the dependencies among the unresolved
+// nodes of a document, given by the references used by their expressions.
+type dependencyGraph struct {
+	nodes []dynaml.UnresolvedNode
+	paths []string
+	edges [][]int
+}
+
+func newDependencyGraph(root yaml.Node, unresolved []dynaml.UnresolvedNode) *dependencyGraph {
+	g := &dependencyGraph{nodes: unresolved}
+	for _, n := range unresolved {
+		g.paths = append(g.paths, strings.Join(n.Context, "."))
+	}
+	for _, n := range unresolved {
+		g.edges = append(g.edges, g.dependencies(root, n))
+	}
+	return g
+}
+
+// dependencies determines the indices of the unresolved nodes a
+// node depends on.
+func (g *dependencyGraph) dependencies(root yaml.Node, n dynaml.UnresolvedNode) []int {
+	deps := []int{}
+	found := map[int]bool{}
+
+	expr, ok := n.Value().(dynaml.Expression)
+	if !ok {
+		return deps
+	}
+	for _, ref := range dynaml.References(expr) {
+		target, _ := resolveReference(root, n.Context, ref, indexStep)
+		if target == nil {
+			continue
+		}
+		t := strings.Join(target, ".")
+		for i, p := range g.paths {
+			if found[i] {
+				continue
+			}
+			if p == t || strings.HasPrefix(p, t+".") || strings.HasPrefix(t, p+".") {
+				found[i] = true
+				deps = append(deps, i)
+			}
+		}
+	}
+	sort.Ints(deps)
+	return deps
+}
+
+// cycles determines a cycle path for all nodes involved in a cycle.
+func (g *dependencyGraph) cycles() map[int][]string {
+	result := map[int][]string{}
+	for i := range g.nodes {
+		if cycle := g.cycle(i); cycle != nil {
+			result[i] = cycle
+		}
+	}
+	return result
+}
+
+// cycle determines the shortest dependency path leading from
+// a node back to itself.
+func (g *dependencyGraph) cycle(start int) []string {
+	prev := map[int]int{}
+	queue := []int{start}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, next := range g.edges[cur] {
+			if next == start {
+				path := []string{g.paths[start]}
+				for n := cur; n != start; n = prev[n] {
+					path = append([]string{g.paths[n]}, path...)
+				}
+				return append([]string{g.paths[start]}, path...)
+			}
+			if _, ok := prev[next]; !ok {
+				prev[next] = cur
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// markCycles adds the cycle paths as issue to all unresolved nodes
+// involved in a cycle.
+func markCycles(root yaml.Node, unresolved []dynaml.UnresolvedNode) []dynaml.UnresolvedNode {
+	g := newDependencyGraph(root, unresolved)
+	cycles := g.cycles()
+	if len(cycles) == 0 {
+		return unresolved
+	}
+	result := make([]dynaml.UnresolvedNode, len(unresolved))
+	for i, n := range unresolved {
+		if cycle, ok := cycles[i]; ok && dynaml.Classification(n) == dynaml.CLASS_CYCLE {
+			n.Node = yaml.IssueNode(n.Node, false, false, yaml.NewIssue("cycle detected: %s", strings.Join(cycle, " -> ")))
+		}
+		result[i] = n
+	}
+	return result
+}
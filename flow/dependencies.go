@@ -0,0 +1,329 @@
+package flow
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+var listIndex = regexp.MustCompile(`^\[-?\d+\]$`)
+
+// dependencies describes the dependencies among the nodes of a document
+// given by the references of their dynaml expressions. It is determined
+// before every evaluation pass of a document and used
+//   - to evaluate the fields of a map in topological order, so that
+//     dependent fields see the evaluated value in the same pass, and
+//   - to re-evaluate unresolved nodes only if a node they depend on
+//     has been changed.
+//
+// Paths are given as used for the evaluation, list entries are denoted
+// by their key (for example name:alice) or by their index.
+type dependencies struct {
+	// targets maps the path of an expression node to the paths
+	// of the nodes it depends on
+	targets map[string][][]string
+	// incomplete marks the expression nodes whose dependencies cannot
+	// be determined before their evaluation
+	incomplete map[string]bool
+	// fields maps the path of a map to the dependencies among its fields
+	fields map[string]map[string][]string
+}
+
+func newDependencies(root yaml.Node) *dependencies {
+	d := &dependencies{
+		targets:    map[string][][]string{},
+		incomplete: map[string]bool{},
+		fields:     map[string]map[string][]string{},
+	}
+	d.collect(root, root, []string{})
+	return d
+}
+
+func pathKey(path []string) string {
+	return strings.Join(path, "\000")
+}
+
+func (d *dependencies) collect(root, node yaml.Node, path []string) {
+	if node == nil {
+		return
+	}
+	switch v := node.Value().(type) {
+	case map[string]yaml.Node:
+		for k, e := range v {
+			if k != "<<" && k != yaml.MERGEKEY {
+				d.collect(root, e, addStep(path, k))
+			}
+		}
+	case []yaml.Node:
+		key := node.KeyName()
+		for i, e := range v {
+			d.collect(root, e, addStep(path, flowStep(i, e, key)))
+		}
+	case dynaml.StaticallyScopedValue:
+		// lambda and template values are evaluated in their own scope
+	case dynaml.Expression:
+		d.add(root, path, v)
+	case string:
+		if sub := yaml.EmbeddedDynaml(node); sub != nil {
+			if expr, err := dynaml.Parse(*sub, path, path); err == nil {
+				d.add(root, path, expr)
+			}
+		}
+	}
+}
+
+func (d *dependencies) add(root yaml.Node, path []string, expr dynaml.Expression) {
+	key := pathKey(path)
+	refs, complete := dynaml.Dependencies(expr)
+	targets := [][]string{}
+	for _, ref := range refs {
+		target, node := resolveReference(root, path, ref, flowStep)
+		if target == nil {
+			complete = false
+			continue
+		}
+		if _, ok := node.Value().(dynaml.StaticallyScopedValue); ok {
+			// lambdas and templates depend on their definition scope
+			complete = false
+		}
+		targets = append(targets, target)
+		d.addFieldDependency(path, target)
+	}
+	d.targets[key] = targets
+	if !complete {
+		d.incomplete[key] = true
+	}
+}
+
+// addFieldDependency records the dependency between the fields of the
+// nearest common map of two dependent nodes.
+func (d *dependencies) addFieldDependency(path, target []string) {
+	n := 0
+	for n < len(path) && n < len(target) && path[n] == target[n] {
+		n++
+	}
+	if n == len(path) || n == len(target) {
+		return
+	}
+	key := pathKey(path[:n])
+	fields := d.fields[key]
+	if fields == nil {
+		fields = map[string][]string{}
+		d.fields[key] = fields
+	}
+	fields[path[n]] = append(fields[path[n]], target[n])
+}
+
+// order provides the evaluation order for the fields of the map
+// at the given path. Fields are evaluated after the fields they depend on.
+// Otherwise, and for cyclic dependencies, the given order is kept.
+func (d *dependencies) order(path []string, keys []string) []string {
+	if d == nil {
+		return keys
+	}
+	fields := d.fields[pathKey(path)]
+	if len(fields) == 0 {
+		return keys
+	}
+	known := map[string]bool{}
+	for _, k := range keys {
+		known[k] = true
+	}
+	result := make([]string, 0, len(keys))
+	visited := map[string]bool{}
+	var visit func(string)
+	visit = func(k string) {
+		if visited[k] {
+			return
+		}
+		visited[k] = true
+		deps := fields[k]
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if known[dep] {
+				visit(dep)
+			}
+		}
+		result = append(result, k)
+	}
+	// merges must be handled first
+	for _, k := range keys {
+		if k == "<<" || k == yaml.MERGEKEY {
+			visit(k)
+		}
+	}
+	for _, k := range keys {
+		visit(k)
+	}
+	return result
+}
+
+// changes checks whether a node has been changed after the given
+// evaluation sequence.
+type changes interface {
+	changedSince(path []string, seq int) bool
+}
+
+// unchanged checks whether no dependency of the expression node at the
+// given path has been changed after its evaluation with sequence seq.
+func (d *dependencies) unchanged(key string, c changes, seq int) bool {
+	if d == nil || d.incomplete[key] {
+		return false
+	}
+	targets, ok := d.targets[key]
+	if !ok {
+		return false
+	}
+	for _, t := range targets {
+		if c.changedSince(t, seq) {
+			return false
+		}
+	}
+	return true
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// stepFunction determines the path step for a list entry
+type stepFunction func(index int, entry yaml.Node, keyName string) string
+
+// flowStep determines the path step used by the evaluation
+// for a list entry (see stepName).
+func flowStep(index int, entry yaml.Node, keyName string) string {
+	if keyName == "" {
+		keyName = "name"
+	}
+	if name, ok := yaml.FindString(entry, keyName); ok {
+		return keyName + ":" + name
+	}
+	return indexStep(index, entry, keyName)
+}
+
+// indexStep denotes a list entry by its index.
+func indexStep(index int, entry yaml.Node, keyName string) string {
+	return fmt.Sprintf("[%d]", index)
+}
+
+func addStep(path []string, step string) []string {
+	result := make([]string, len(path)+1)
+	copy(result, path)
+	result[len(path)] = step
+	return result
+}
+
+// resolveReference determines the document path and node of a reference
+// used by a node at the given path, following the scoping rules of
+// dynaml references. Nested maps are scopes for the resolution of
+// relative references.
+func resolveReference(root yaml.Node, context []string, ref []string, step stepFunction) ([]string, yaml.Node) {
+	if len(ref) == 0 || ref[0] == yaml.SELF || ref[0] == yaml.DOCNODE {
+		return nil, nil
+	}
+	if ref[0] == "" {
+		return followPath(root, nil, ref[1:], step)
+	}
+	for i := len(context) - 1; i >= 0; i-- {
+		scope := findPath(root, context[:i])
+		if scope == nil {
+			continue
+		}
+		if m, ok := scope.Value().(map[string]yaml.Node); ok {
+			if _, ok := m[ref[0]]; ok {
+				return followPath(scope, context[:i], ref, step)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// findPath looks up a node for a path as determined by resolveReference.
+func findPath(node yaml.Node, path []string) yaml.Node {
+	for _, s := range path {
+		if node == nil {
+			return nil
+		}
+		switch v := node.Value().(type) {
+		case map[string]yaml.Node:
+			node = v[s]
+		case []yaml.Node:
+			var found yaml.Node
+			if listIndex.MatchString(s) {
+				i, _ := strconv.Atoi(s[1 : len(s)-1])
+				if i >= 0 && i < len(v) {
+					found = v[i]
+				}
+			} else if i := strings.Index(s, ":"); i > 0 {
+				for _, e := range v {
+					if n, ok := yaml.FindString(e, s[:i]); ok && n == s[i+1:] {
+						found = e
+						break
+					}
+				}
+			}
+			node = found
+		default:
+			return nil
+		}
+	}
+	return node
+}
+
+// followPath follows a reference path starting at a node and returns the
+// resulting path using the given step function for all list entries
+// together with the found node.
+func followPath(node yaml.Node, prefix []string, path []string, step stepFunction) ([]string, yaml.Node) {
+	result := append([]string{}, prefix...)
+	for n, s := range path {
+		if node == nil {
+			return nil, nil
+		}
+		switch v := node.Value().(type) {
+		case map[string]yaml.Node:
+			next, ok := v[s]
+			if !ok {
+				return nil, nil
+			}
+			node = next
+		case []yaml.Node:
+			keyName := node.KeyName()
+			index := -1
+			if listIndex.MatchString(s) {
+				index, _ = strconv.Atoi(s[1 : len(s)-1])
+				if index < 0 {
+					index += len(v)
+				}
+			} else {
+				key := keyName
+				if key == "" {
+					key = "name"
+				}
+				name := s
+				if i := strings.Index(s, ":"); i > 0 {
+					key = s[:i]
+					name = s[i+1:]
+				}
+				for i, e := range v {
+					if found, ok := yaml.FindStringR(true, e, key); ok && found == name {
+						index = i
+						break
+					}
+				}
+			}
+			if index < 0 || index >= len(v) {
+				return nil, nil
+			}
+			node = v[index]
+			s = step(index, node, keyName)
+		default:
+			// remaining path refers to the inside of an unresolved node
+			return append(result, path[n:]...), node
+		}
+		result = append(result, s)
+	}
+	return result, node
+}
@@ -0,0 +1,86 @@
+package flow
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dependencies", func() {
+
+	It("orders fields after the fields they depend on", func() {
+		source := parseYAML(`
+---
+a: (( b.x ))
+b:
+  x: (( c ))
+c: (( d ))
+d: 1
+`)
+		deps := newDependencies(source)
+		Expect(deps.order([]string{}, []string{"a", "b", "c", "d"})).To(Equal([]string{"d", "c", "b", "a"}))
+		Expect(deps.order([]string{"b"}, []string{"x"})).To(Equal([]string{"x"}))
+	})
+
+	It("keeps merges first", func() {
+		source := parseYAML(`
+---
+<<: (( merge ))
+a: (( b ))
+b: 1
+`)
+		deps := newDependencies(source)
+		Expect(deps.order([]string{}, []string{"<<", "a", "b"})).To(Equal([]string{"<<", "b", "a"}))
+	})
+
+	It("resolves list entries to flow steps", func() {
+		source := parseYAML(`
+---
+jobs:
+  - name: api
+    port: 1
+  - name: db
+    port: (( jobs.api.port ))
+  - port: (( jobs.[1].port ))
+`)
+		deps := newDependencies(source)
+		Expect(deps.targets[pathKey([]string{"jobs", "name:db", "port"})]).To(Equal([][]string{{"jobs", "name:api", "port"}}))
+		Expect(deps.targets[pathKey([]string{"jobs", "[2]", "port"})]).To(Equal([][]string{{"jobs", "name:db", "port"}}))
+	})
+
+	It("marks lambda calls as incomplete", func() {
+		source := parseYAML(`
+---
+f: (( |x|->x ))
+a: (( .f(1) ))
+`)
+		deps := newDependencies(source)
+		Expect(deps.incomplete[pathKey([]string{"a"})]).To(BeTrue())
+	})
+
+	It("evaluates long reference chains", func() {
+		n := 1000
+		src := []string{"---", "vals:"}
+		exp := []string{"---", "vals:"}
+		for i := 0; i < n; i++ {
+			src = append(src, fmt.Sprintf("  a%d: (( .vals.a%d + 1 ))", i, i+1))
+			exp = append(exp, fmt.Sprintf("  a%d: %d", i, n-i))
+		}
+		src = append(src, fmt.Sprintf("  a%d: 0", n))
+		exp = append(exp, fmt.Sprintf("  a%d: 0", n))
+		src = append(src, "rev:")
+		exp = append(exp, "rev:")
+		for i := 0; i < n; i++ {
+			src = append(src, fmt.Sprintf("  b%d: (( b%d + 1 ))", i+1, i))
+			exp = append(exp, fmt.Sprintf("  b%d: %d", i+1, i+1))
+		}
+		src = append(src, "  b0: 0")
+		exp = append(exp, "  b0: 0")
+
+		source := parseYAML(strings.Join(src, "\n"))
+		resolved := parseYAML(strings.Join(exp, "\n"))
+		Expect(source).To(FlowAs(resolved))
+	})
+})
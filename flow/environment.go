@@ -2,9 +2,7 @@ package flow
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
-	"reflect"
 	"strings"
 
 	"github.com/mandelsoft/spiff/debug"
@@ -49,7 +47,9 @@ type DefaultEnvironment struct {
 	static map[string]yaml.Node
 	outer  dynaml.Binding
 
-	active bool
+	active      bool
+	evaluation  *evaluation
+	explanation *Explanation
 }
 
 func keys(s map[string]yaml.Node) string {
@@ -127,6 +127,9 @@ func (e DefaultEnvironment) CurrentSourceName() string {
 }
 
 func (e DefaultEnvironment) GetRootBinding() map[string]yaml.Node {
+	if e.scope == nil {
+		return nil
+	}
 	return e.scope.root.local
 }
 
@@ -143,11 +146,44 @@ func (e DefaultEnvironment) FindFromRoot(path []string) (yaml.Node, bool) {
 		return nil, false
 	}
 
+	if scope := e.liveScope(path); scope != nil {
+		return yaml.FindR(true, yaml.NewNode(scope.local, "scope"), path[len(scope.path):]...)
+	}
 	return yaml.FindR(true, yaml.NewNode(e.scope.root.local, "scope"), path...)
 }
 
+// liveScope determines the innermost enclosing map scope of the evaluation
+// of a document containing the node with the given document path. Such a
+// scope already provides the fields evaluated in the actual evaluation pass.
+func (e DefaultEnvironment) liveScope(path []string) *Scope {
+	if e.evaluation == nil || !e.evaluation.toplevel {
+		return nil
+	}
+	for scope := e.scope; scope != nil && scope.path != nil; scope = scope.next {
+		if len(scope.path) < len(path) && isPrefix(scope.path, path) {
+			return scope
+		}
+	}
+	return nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	for i, s := range prefix {
+		if path[i] != s {
+			return false
+		}
+	}
+	return true
+}
+
 func (e DefaultEnvironment) FindReference(path []string) (yaml.Node, bool) {
 	root, found, nodescope := resolveSymbol(&e, path[0], e.scope)
+	if found && path[0] != yaml.SELF && nodescope != nil && (nodescope.path != nil || nodescope == nodescope.root) {
+		abs := append(append([]string{}, nodescope.path...), path...)
+		if scope := e.liveScope(abs); scope != nil && len(scope.path) > len(nodescope.path) {
+			return yaml.FindR(true, yaml.NewNode(scope.local, "scope"), abs[len(scope.path):]...)
+		}
+	}
 	if !found {
 		//fmt.Printf("FIND %s: %s\n", strings.Join(path,"."), e)
 		//fmt.Printf("FOUND %s: %v\n", strings.Join(path,"."),  keys(nodescope))
@@ -247,28 +283,43 @@ func (e DefaultEnvironment) RedirectOverwrite(path []string) dynaml.Binding {
 func (e DefaultEnvironment) Flow(source yaml.Node, shouldOverride bool) (yaml.Node, dynaml.Status) {
 	result := source
 
+	e.evaluation = newEvaluation(e.scope == nil && len(e.path) == 0)
+	if e.explanation != nil {
+		e.explanation.depth++
+		defer func() { e.explanation.depth-- }()
+	}
+	verify := false
 	for {
 		debug.Debug("@@{ loop:  %+v\n", result)
+		if e.explanation != nil && e.explanation.depth == 1 {
+			e.explanation.pass++
+			e.explanation.resolve(result)
+		}
+		e.evaluation.pass(result, verify)
 		next := flow(result, e, shouldOverride)
 		if next.Undefined() {
 			next = node(nil)
 		}
 		debug.Debug("@@} --->   %+v\n", next)
 
-		next = Cleanup(next, updateBinding(next))
-		b := reflect.DeepEqual(result, next)
-		//b,r:=yaml.Equals(result, next,[]string{})
-		if b {
-			break
+		Walk(next, updateBinding(next))
+		if identical(result, next) {
+			// unresolved nodes skipped for unchanged dependencies
+			// are finally evaluated again to verify the result
+			if verify || e.evaluation.skipped == 0 {
+				break
+			}
+			verify = true
+		} else {
+			verify = false
 		}
-		//fmt.Printf("****** found diff: %s\n", r)
 		result = next
 	}
 	debug.Debug("@@@ Done\n")
 	result = Cleanup(result, deactivateScopes)
 	unresolved := dynaml.FindUnresolvedNodes(result)
 	if len(unresolved) > 0 {
-		return result, dynaml.UnresolvedNodes{unresolved}
+		return result, dynaml.UnresolvedNodes{markCycles(result, unresolved)}
 	}
 
 	return result, nil
@@ -289,10 +340,20 @@ func CleanupEnvironment(binding dynaml.Binding) {
 	}
 }
 
+// NewEnvironmentWithState creates a top level environment using the
+// given state, for example configured with a dedicated file system.
+// It can be used as outer binding for the processing functions.
+func NewEnvironmentWithState(stubs []yaml.Node, source string, state *State) dynaml.Binding {
+	if state == nil {
+		state = NewState(DefaultEncryptionKey())
+	}
+	return DefaultEnvironment{state: state, stubs: stubs, sourceName: source, currentSourceName: source, active: true}
+}
+
 func NewNestedEnvironment(stubs []yaml.Node, source string, outer dynaml.Binding) dynaml.Binding {
 	var state *State
 	if outer == nil {
-		state = NewState(os.Getenv("SPIFF_ENCRYPTION_KEY"))
+		state = NewState(DefaultEncryptionKey())
 	}
 	return DefaultEnvironment{state: state, stubs: stubs, sourceName: source, currentSourceName: source, outer: outer, active: true}
 }
@@ -303,9 +364,8 @@ type Updateable interface {
 	Deactivate() dynaml.Binding
 }
 
-func updateBinding(root yaml.Node) CleanupFunction {
-	var me CleanupFunction
-	me = func(node yaml.Node) (yaml.Node, CleanupFunction) {
+func updateBinding(root yaml.Node) func(yaml.Node) {
+	return func(node yaml.Node) {
 		if v := node.Value(); v != nil {
 			if static, ok := v.(dynaml.StaticallyScopedValue); ok {
 				debug.Debug("update found static scoped %q\n", static)
@@ -326,9 +386,7 @@ func updateBinding(root yaml.Node) CleanupFunction {
 				}
 			}
 		}
-		return node, me
 	}
-	return me
 }
 
 func deactivateScopes(node yaml.Node) (yaml.Node, CleanupFunction) {
@@ -355,7 +413,7 @@ func resolveSymbol(env *DefaultEnvironment, name string, scope *Scope) (yaml.Nod
 		}
 		val := scope.local[name]
 		if val != nil {
-			return val, true, nil
+			return val, true, scope
 		}
 		scope = scope.next
 	}
@@ -387,12 +445,14 @@ func createContext(env *DefaultEnvironment) yaml.Node {
 		path[i] = node(v)
 	}
 	ctx["STUBPATH"] = node(path)
-	if outer := env.Outer(); outer != nil {
-		list := []yaml.Node{}
-		for outer != nil {
-			list = append(list, node(outer.GetRootBinding()))
-			outer = outer.Outer()
+	list := []yaml.Node{}
+	for outer := env.Outer(); outer != nil; outer = outer.Outer() {
+		// skip outer environments just providing a state
+		if root := outer.GetRootBinding(); root != nil {
+			list = append(list, node(root))
 		}
+	}
+	if len(list) > 0 {
 		ctx["OUTER"] = node(list)
 	}
 	return node(ctx)
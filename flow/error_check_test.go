@@ -13,7 +13,7 @@ var _ = Describe("Reporting issues for unresolved nodes", func() {
 node: (( ref ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( ref ))	in test	node	()	*'ref' not found`,
+			`	(( ref ))	in test:3:7	node	()	*'ref' not found`,
 		))
 	})
 
@@ -24,7 +24,7 @@ a: true
 node: (( a + 1 ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( a + 1 ))	in test	node	()	*first argument of PLUS must be IP address or integer`,
+			`	(( a + 1 ))	in test:4:7	node	()	*first argument of PLUS must be IP address or number`,
 		))
 	})
 
@@ -35,7 +35,7 @@ a: true
 node: (( a - 1 ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( a - 1 ))	in test	node	()	*first argument of MINUS must be IP address or integer`,
+			`	(( a - 1 ))	in test:4:7	node	()	*first argument of MINUS must be IP address or number`,
 		))
 	})
 
@@ -46,7 +46,7 @@ a: 1
 node: (( a / 0 ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( a / 0 ))	in test	node	()	*division by zero`,
+			`	(( a / 0 ))	in test:4:7	node	()	*division by zero`,
 		))
 	})
 
@@ -57,7 +57,7 @@ a: 1
 node: (( a / true ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( a / true ))	in test	node	()	*integer operand required`,
+			`	(( a / true ))	in test:4:7	node	()	*integer operand required`,
 		))
 	})
 
@@ -67,7 +67,7 @@ node: (( a / true ))
 node: (( merge ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( merge ))	in test	node	(node)	*'node' not found in any stub`,
+			`	(( merge ))	in test:3:7	node	(node)	*'node' not found in any stub`,
 		))
 	})
 
@@ -77,7 +77,7 @@ node: (( merge ))
 node: (( merge other.node))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( merge other.node ))	in test	node	(other.node)	*'other.node' not found in any stub`,
+			`	(( merge other.node ))	in test:3:7	node	(other.node)	*'other.node' not found in any stub`,
 		))
 	})
 
@@ -89,7 +89,7 @@ list:
 node: (( join( ",", list.[0] ) ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( join(",", list.[0]) ))	in test	node	()	*argument 1 to join must be simple value or list`,
+			`	(( join(",", list.[0]) ))	in test:5:7	node	()	*argument 1 to join must be simple value or list`,
 		))
 	})
 
@@ -101,7 +101,7 @@ list:
 node: (( join( [], "a" ) ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( join([], "a") ))	in test	node	()	*first argument for join must be a string`,
+			`	(( join([], "a") ))	in test:5:7	node	()	*first argument for join must be a string`,
 		))
 	})
 
@@ -113,7 +113,7 @@ list:
 node: (( join( ",", list ) ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( join(",", list) ))	in test	node	()	*elements of list(arg 1) to join must be simple values`,
+			`	(( join(",", list) ))	in test:5:7	node	()	*elements of list(arg 1) to join must be simple values`,
 		))
 	})
 
@@ -123,7 +123,7 @@ node: (( join( ",", list ) ))
 node: (( min_ip( "10" ) ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( min_ip("10") ))	in test	node	()	*CIDR argument required`,
+			`	(( min_ip("10") ))	in test:3:7	node	()	*CIDR argument required`,
 		))
 	})
 
@@ -135,7 +135,7 @@ a:
 node: (( "." a ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( "." a ))	in test	node	()	*type 'list'(a) cannot be concatenated with type 'string'(".")`,
+			`	(( "." a ))	in test:5:7	node	()	*type 'list'(a) cannot be concatenated with type 'string'(".")`,
 		))
 	})
 
@@ -146,7 +146,7 @@ node: (( "." a ))
 node: (( length( 5 ) ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( length(5) ))	in test	node	()	*invalid type for function length`,
+			`	(( length(5) ))	in test:4:7	node	()	*invalid type for function length`,
 		))
 	})
 
@@ -157,7 +157,7 @@ node: (( length( 5 ) ))
 node: (( select{[5]|x|->x} ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( select{[5]|x|->x} ))	in test	node	()	*list value not supported for select mapping`,
+			`	(( select{[5]|x|->x} ))	in test:4:7	node	()	*list value not supported for select mapping`,
 		))
 	})
 
@@ -168,7 +168,7 @@ node: (( select{[5]|x|->x} ))
 node: (( map{[5]|x|->x} ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( map{[5]|x|->x} ))	in test	node	()	*list value not supported for map mapping`,
+			`	(( map{[5]|x|->x} ))	in test:4:7	node	()	*list value not supported for map mapping`,
 		))
 	})
 
@@ -178,7 +178,7 @@ node: (( map{[5]|x|->x} ))
 node: (( a "." ) ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( a "." ) ))	in test	node	()	*parse error near symbol 7 - symbol 8: ' '`,
+			`	(( a "." ) ))	in test:3:7	node	()	*parse error near symbol 7 - symbol 8: ' '`,
 		))
 	})
 
@@ -189,7 +189,7 @@ node:
   - <<: (( a "." ) ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( a "." ) ))	in test	node.[0].<<	()	*parse error near symbol 7 - symbol 8: ' '`,
+			`	(( a "." ) ))	in test:4:9	node.[0].<<	()	*parse error near symbol 7 - symbol 8: ' '`,
 		))
 	})
 
@@ -200,7 +200,7 @@ node:
   <<: (( a "." ) ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( a "." ) ))	in test	node.<<	()	*parse error near symbol 7 - symbol 8: ' '`,
+			`	(( a "." ) ))	in test:4:7	node.<<	()	*parse error near symbol 7 - symbol 8: ' '`,
 		))
 	})
 
@@ -216,7 +216,36 @@ node:
 		Expect(source).To(FlowToErr(
 			`	((
 	a "." )
-	))	in test	node.<<	()	*parse error near line 2 symbol 6 - line 2 symbol 7: ' '`,
+	))	in test:4:7	node.<<	()	*parse error near line 2 symbol 6 - line 2 symbol 7: ' '`,
 		))
 	})
+
+	It("reports self references as cycle", func() {
+		source := parseYAML(`
+---
+node: (( node ))
+`)
+		Expect(source).To(FlowToErr(
+			`	(( node ))	in test:3:7	node	()	@cycle detected: node -> node`,
+		))
+	})
+
+	It("reports the cycle path for all involved nodes", func() {
+		source := parseYAML(`
+---
+a: (( b.x ))
+b:
+  x: (( list.alice.value ))
+list:
+  - name: alice
+    value: (( a ))
+other: (( a ))
+`)
+		_, err := Flow(source)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("a	()	@cycle detected: a -> b.x -> list.[0].value -> a"))
+		Expect(err.Error()).To(ContainSubstring("b.x	()	@cycle detected: b.x -> list.[0].value -> a -> b.x"))
+		Expect(err.Error()).To(ContainSubstring("list.[0].value	()	@cycle detected: list.[0].value -> a -> b.x -> list.[0].value"))
+		Expect(err.Error()).To(ContainSubstring("other	()	@'a' unresolved"))
+	})
 })
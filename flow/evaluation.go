@@ -0,0 +1,238 @@
+package flow
+
+import (
+	"reflect"
+
+	"github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+// evaluation keeps track of the evaluation of the nodes of a document
+// during the evaluation passes of DefaultEnvironment.Flow.
+//
+// Unchanged nodes are kept by the flow, so that a pass not changing
+// any node returns the identical document. Changes are recorded with a
+// sequence number. An expression node is only evaluated again, if a node
+// it depends on has been changed after its last evaluation. Nodes without
+// any unresolved element are not evaluated again at all.
+type evaluation struct {
+	// toplevel indicates the evaluation of a complete document. Only here
+	// the evaluation paths match the document paths used for dependencies.
+	toplevel bool
+	deps     *dependencies
+	seq      int
+	// changed maps paths to the last change of the node itself
+	changed map[string]int
+	// nested maps paths to the last change of the node or a nested node
+	nested map[string]int
+	nodes  map[string]evaluatedNode
+	// skipped is the number of unresolved nodes skipped in the actual pass
+	skipped int
+	// verify enforces the evaluation of all unresolved nodes
+	verify bool
+}
+
+type evaluatedNode struct {
+	node           yaml.Node
+	seq            int
+	settled        bool
+	stubPath       []string
+	noMerge        bool
+	shouldOverride bool
+}
+
+func newEvaluation(toplevel bool) *evaluation {
+	return &evaluation{
+		toplevel: toplevel,
+		changed:  map[string]int{},
+		nested:   map[string]int{},
+		nodes:    map[string]evaluatedNode{},
+	}
+}
+
+func getEvaluation(env dynaml.Binding) *evaluation {
+	if e, ok := env.(DefaultEnvironment); ok {
+		return e.evaluation
+	}
+	return nil
+}
+
+// pass prepares the evaluation state for the next evaluation pass
+// of a document.
+func (e *evaluation) pass(root yaml.Node, verify bool) {
+	if e.toplevel {
+		e.deps = newDependencies(root)
+	}
+	e.skipped = 0
+	e.verify = verify
+}
+
+// unchanged checks whether the evaluation of a node can be omitted,
+// because it would yield the same result as the last evaluation.
+func (e *evaluation) unchanged(node yaml.Node, env dynaml.Binding, shouldOverride bool) bool {
+	key := pathKey(env.Path())
+	s, ok := e.nodes[key]
+	if !ok || s.shouldOverride != shouldOverride || s.noMerge != env.NoMerge() ||
+		!reflect.DeepEqual(s.stubPath, env.StubPath()) || !identical(s.node, node) {
+		return false
+	}
+	if s.settled {
+		return true
+	}
+	if e.verify || !e.deps.unchanged(key, e, s.seq) {
+		return false
+	}
+	e.skipped++
+	return true
+}
+
+// update records the result of the evaluation of a node. If the result
+// is unchanged, the original node is returned.
+func (e *evaluation) update(node, result yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
+	path := env.Path()
+	switch {
+	case unchangedNode(node, result):
+		result = node
+	case structureChanged(node, result):
+		e.seq++
+		e.changed[pathKey(path)] = e.seq
+		for i := 0; i <= len(path); i++ {
+			e.nested[pathKey(path[:i])] = e.seq
+		}
+	}
+	if isLeaf(result) {
+		e.nodes[pathKey(path)] = evaluatedNode{result, e.seq, isSettled(result), env.StubPath(), env.NoMerge(), shouldOverride}
+	}
+	return result
+}
+
+// changedSince checks whether a node, a nested node or the
+// structure of an enclosing node has been changed after the given
+// sequence number.
+func (e *evaluation) changedSince(path []string, seq int) bool {
+	if e.nested[pathKey(path)] > seq {
+		return true
+	}
+	for i := 0; i < len(path); i++ {
+		if e.changed[pathKey(path[:i])] > seq {
+			return true
+		}
+	}
+	return false
+}
+
+// identical checks whether two nodes are identical. Maps and lists
+// are compared by identity, the value of other nodes by equality.
+func identical(a, b yaml.Node) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if !sameAttributes(a, b) {
+		return false
+	}
+	switch va := a.Value().(type) {
+	case map[string]yaml.Node:
+		vb, ok := b.Value().(map[string]yaml.Node)
+		return ok && reflect.ValueOf(va).Pointer() == reflect.ValueOf(vb).Pointer()
+	case []yaml.Node:
+		vb, ok := b.Value().([]yaml.Node)
+		return ok && len(va) == len(vb) && (len(va) == 0 || &va[0] == &vb[0])
+	default:
+		return reflect.DeepEqual(a.Value(), b.Value())
+	}
+}
+
+func sameAttributes(a, b yaml.Node) bool {
+	if a.SourceName() != b.SourceName() || a.SourceLine() != b.SourceLine() || a.SourceColumn() != b.SourceColumn() {
+		return false
+	}
+	if (a.Resolver() != nil || b.Resolver() != nil) && !reflect.DeepEqual(a.Resolver(), b.Resolver()) {
+		return false
+	}
+	return reflect.DeepEqual(a.GetAnnotation(), b.GetAnnotation())
+}
+
+// unchangedNode checks whether the evaluation of a node yields an equivalent
+// node. The nested nodes of maps and lists must be identical.
+func unchangedNode(node, result yaml.Node) bool {
+	if node == nil || result == nil {
+		return node == nil && result == nil
+	}
+	switch r := result.Value().(type) {
+	case map[string]yaml.Node:
+		n, ok := node.Value().(map[string]yaml.Node)
+		if !ok || len(n) != len(r) || !sameAttributes(node, result) {
+			return false
+		}
+		for k, v := range r {
+			if !identical(n[k], v) {
+				return false
+			}
+		}
+		return true
+	case []yaml.Node:
+		n, ok := node.Value().([]yaml.Node)
+		if !ok || len(n) != len(r) || !sameAttributes(node, result) {
+			return false
+		}
+		for i, v := range r {
+			if !identical(n[i], v) {
+				return false
+			}
+		}
+		return true
+	default:
+		return identical(node, result)
+	}
+}
+
+// structureChanged checks whether a changed result of an evaluation
+// is not just caused by changed nested nodes, which are recorded on
+// their own.
+func structureChanged(node, result yaml.Node) bool {
+	if node == nil || result == nil || !sameAttributes(node, result) {
+		return true
+	}
+	switch r := result.Value().(type) {
+	case map[string]yaml.Node:
+		n, ok := node.Value().(map[string]yaml.Node)
+		if !ok || len(n) != len(r) {
+			return true
+		}
+		for k := range r {
+			if _, ok := n[k]; !ok {
+				return true
+			}
+		}
+		return false
+	default:
+		// list entries might be replaced by merges
+		return true
+	}
+}
+
+func isLeaf(node yaml.Node) bool {
+	if node == nil {
+		return false
+	}
+	switch node.Value().(type) {
+	case map[string]yaml.Node, []yaml.Node:
+		return false
+	}
+	return true
+}
+
+// isSettled checks whether a leaf node does not contain
+// any unresolved element anymore.
+func isSettled(node yaml.Node) bool {
+	if node.Failed() || node.HasError() || node.Undefined() {
+		return false
+	}
+	switch node.Value().(type) {
+	case dynaml.Expression:
+		return false
+	case string:
+		return yaml.EmbeddedDynaml(node) == nil
+	}
+	return true
+}
@@ -0,0 +1,253 @@
+package flow
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+// Explanation records the evaluation steps of the top level flow
+// for a dedicated node path of a document.
+type Explanation struct {
+	Path   []string
+	Steps  []ExplanationStep
+	Result yaml.Node
+	Found  bool
+	// OmittedBy is the path of the temporary or local node
+	// omitting the node from the final document
+	OmittedBy []string
+
+	depth int
+	pass  int
+	// steps is the requested path resolved to the path steps
+	// used by the flow (see flowStep)
+	steps []string
+}
+
+type ExplanationStep struct {
+	Pass    int
+	Message string
+}
+
+func NewExplanation(path []string) *Explanation {
+	return &Explanation{Path: path}
+}
+
+func (x *Explanation) String() string {
+	s := fmt.Sprintf("path: %s\n", strings.Join(x.Path, "."))
+	pass := 0
+	for _, step := range x.Steps {
+		if step.Pass != pass {
+			pass = step.Pass
+			s += fmt.Sprintf("pass %d:\n", pass)
+		}
+		s += fmt.Sprintf("  %s\n", strings.Replace(step.Message, "\n", "\n    ", -1))
+	}
+	if x.Found {
+		s += fmt.Sprintf("final value: %s\n", describeNode(x.Result))
+		if x.OmittedBy != nil {
+			s += fmt.Sprintf("omitted from the final document by %s\n", strings.Join(x.OmittedBy, "."))
+		}
+	} else {
+		s += "final value: <not found>\n"
+	}
+	return s
+}
+
+// resolve maps the requested path to the path steps used by the flow
+// for the given document. Names and indices of list entries are mapped
+// to the step used for the entry, for example name:api.
+func (x *Explanation) resolve(root yaml.Node) {
+	if steps, _ := followPath(root, nil, x.Path, flowStep); steps != nil {
+		x.steps = steps
+	} else {
+		x.steps = x.Path
+	}
+}
+
+func (x *Explanation) add(msg string, args ...interface{}) {
+	x.Steps = append(x.Steps, ExplanationStep{x.pass, fmt.Sprintf(msg, args...)})
+}
+
+// Explain flows a template like Apply, but additionally
+// records the explanation of the evaluation of the given path.
+func Explain(outer dynaml.Binding, template yaml.Node, prepared []yaml.Node, path []string) (yaml.Node, *Explanation, error) {
+	x := NewExplanation(path)
+	env := NewNestedEnvironment(prepared, template.SourceName(), outer).(DefaultEnvironment)
+	env.explanation = x
+	defer CleanupEnvironment(env)
+
+	result, err := env.Flow(template, true)
+	if result != nil {
+		x.Result, x.Found = yaml.FindR(true, result, path...)
+		for i := len(path); x.Found && i >= 0; i-- {
+			n, _ := yaml.FindR(true, result, path[:i]...)
+			if n.Temporary() || n.Local() {
+				x.OmittedBy = path[:i]
+				break
+			}
+		}
+	}
+	if err == nil {
+		result = Cleanup(result, discardTemporary)
+	}
+	return result, x, err
+}
+
+// explaining returns the explanation to use for the actual node, if
+// the node is the requested one of the top level flow.
+func explaining(env dynaml.Binding) *Explanation {
+	e, ok := env.(DefaultEnvironment)
+	if !ok || e.explanation == nil || e.explanation.depth != 1 {
+		return nil
+	}
+	path := env.Path()
+	if len(path) != len(e.explanation.steps) {
+		return nil
+	}
+	for i, p := range path {
+		if p != e.explanation.steps[i] {
+			return nil
+		}
+	}
+	return e.explanation
+}
+
+func explain(env dynaml.Binding, msg string, args ...interface{}) {
+	if x := explaining(env); x != nil {
+		x.add(msg, args...)
+	}
+}
+
+// explainReferences describes the nodes referenced by an expression.
+func explainReferences(env dynaml.Binding, expr dynaml.Expression) {
+	x := explaining(env)
+	if x == nil {
+		return
+	}
+	for _, ref := range dynaml.References(expr) {
+		var node yaml.Node
+		var ok bool
+		if ref[0] == "" {
+			node, ok = env.FindFromRoot(ref[1:])
+		} else {
+			node, ok = env.FindReference(ref)
+		}
+		// unknown references are omitted, they might denote builtin functions
+		if ok {
+			x.add("reference %s (%s): %s", strings.Join(ref, "."), node.SourceLocation(), describeNode(node))
+		}
+	}
+}
+
+// explainMerge describes the stub node providing the value
+// of a merge expression.
+func explainMerge(env dynaml.Binding, m dynaml.MergeExpr) {
+	x := explaining(env)
+	if x == nil {
+		return
+	}
+	x.add("merge with stub path %s", strings.Join(m.Path, "."))
+	if len(m.Path) == 0 {
+		return
+	}
+	if node, ok := env.FindInStubs(m.Path); ok {
+		x.add("merged stub value (%s): %s", node.SourceLocation(), description{node})
+	}
+}
+
+// explainResult describes the merge and marker decisions
+// made for a node.
+func explainResult(env dynaml.Binding, node yaml.Node) {
+	x := explaining(env)
+	if x == nil || node == nil {
+		return
+	}
+	decisions := []string{}
+	flags := node.Flags()
+	if flags.Temporary() {
+		decisions = append(decisions, dynaml.TEMPORARY)
+	}
+	if flags.Local() {
+		decisions = append(decisions, dynaml.LOCAL)
+	}
+	if flags.State() {
+		decisions = append(decisions, dynaml.STATE)
+	}
+	if flags.Default() {
+		decisions = append(decisions, dynaml.DEFAULT)
+	} else {
+		if flags.Inject() {
+			decisions = append(decisions, dynaml.INJECT)
+		}
+	}
+	if node.Merged() {
+		decisions = append(decisions, "merged")
+	}
+	if node.ReplaceFlag() {
+		decisions = append(decisions, "merge replace")
+	}
+	if node.RedirectPath() != nil {
+		decisions = append(decisions, fmt.Sprintf("redirected to stub path %s", strings.Join(node.RedirectPath(), ".")))
+	}
+	if node.KeyName() != "" {
+		decisions = append(decisions, fmt.Sprintf("list entries merged on key %s", node.KeyName()))
+	}
+	if node.Preferred() {
+		decisions = append(decisions, "preferred")
+	}
+	if len(decisions) > 0 {
+		x.add("decisions: %s", strings.Join(decisions, ", "))
+	}
+}
+
+// description defers the description of a node until it is
+// formatted for an explanation.
+type description struct {
+	node yaml.Node
+}
+
+func (d description) String() string {
+	return describeNode(d.node)
+}
+
+func describeNode(node yaml.Node) string {
+	if node == nil {
+		return "~"
+	}
+	switch v := node.Value().(type) {
+	case nil:
+		return "~"
+	case string:
+		return strconv.Quote(v)
+	case dynaml.Expression:
+		return fmt.Sprintf("(( %s ))", v)
+	case dynaml.LambdaValue:
+		return v.String()
+	case dynaml.TemplateValue:
+		return "<template>"
+	case map[string]yaml.Node:
+		keys := []string{}
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fields := []string{}
+		for _, k := range keys {
+			fields = append(fields, fmt.Sprintf("%s: %s", k, describeNode(v[k])))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	case []yaml.Node:
+		entries := []string{}
+		for _, e := range v {
+			entries = append(entries, describeNode(e))
+		}
+		return "[" + strings.Join(entries, ", ") + "]"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
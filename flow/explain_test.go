@@ -0,0 +1,148 @@
+package flow
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+func explanationFor(path string, source yaml.Node, stubs ...yaml.Node) *Explanation {
+	prepared, err := PrepareStubs(nil, false, stubs...)
+	Expect(err).NotTo(HaveOccurred())
+	_, x, err := Explain(nil, source, prepared, strings.Split(path, "."))
+	Expect(err).NotTo(HaveOccurred())
+	return x
+}
+
+func explanationMessages(x *Explanation) []string {
+	msgs := []string{}
+	for _, s := range x.Steps {
+		msgs = append(msgs, s.Message)
+	}
+	return msgs
+}
+
+var _ = Describe("Explaining the evaluation of a node", func() {
+
+	It("reports the expression and the referenced nodes", func() {
+		source := parseYAML(`
+---
+base: 10
+node: (( base + 1 ))
+`)
+		x := explanationFor("node", source)
+		Expect(x.Found).To(BeTrue())
+		Expect(x.Result.Value()).To(Equal(int64(11)))
+		Expect(explanationMessages(x)).To(ContainElement(`reference base (test:3:7): 10`))
+		Expect(explanationMessages(x)).To(ContainElement(`evaluates to 11`))
+	})
+
+	It("resolves paths through named list entries", func() {
+		source := parseYAML(`
+---
+base: 8080
+jobs:
+  - name: db
+    properties:
+      port: 5432
+  - name: api
+    properties:
+      port: (( base + 1 ))
+`)
+		x := explanationFor("jobs.api.properties.port", source)
+		Expect(x.Found).To(BeTrue())
+		Expect(x.Result.Value()).To(Equal(int64(8081)))
+		Expect(explanationMessages(x)).To(ContainElement(`reference base (test:3:7): 8080`))
+		Expect(explanationMessages(x)).To(ContainElement(`evaluates to 8081`))
+	})
+
+	It("resolves paths through list indices", func() {
+		source := parseYAML(`
+---
+jobs:
+  - name: db
+  - name: api
+    port: (( 1 + 1 ))
+`)
+		x := explanationFor("jobs.[1].port", source)
+		Expect(x.Found).To(BeTrue())
+		Expect(explanationMessages(x)).To(ContainElement(`evaluates to 2`))
+	})
+
+	It("reports overriding stub values", func() {
+		source := parseYAML(`
+---
+node: (( 1 + 1 ))
+`)
+		stub := parseYAML(`
+---
+node: 5
+`)
+		x := explanationFor("node", source, stub)
+		Expect(x.Result.Value()).To(Equal(int64(5)))
+		Expect(explanationMessages(x)).To(ContainElement(`overridden by stub value (test:3:7): 5`))
+	})
+
+	It("ignores default values in stubs", func() {
+		source := parseYAML(`
+---
+node: 1
+`)
+		stub := parseYAML(`
+---
+node: (( &default(5) ))
+`)
+		x := explanationFor("node", source, stub)
+		Expect(x.Result.Value()).To(Equal(int64(1)))
+		Expect(explanationMessages(x)).To(ContainElement(`stub value (test:3:7) is marked as &default, keep template value`))
+	})
+
+	It("reports the stub values of merges", func() {
+		source := parseYAML(`
+---
+node: (( merge ))
+`)
+		stub := parseYAML(`
+---
+other: 1
+node: 5
+`)
+		x := explanationFor("node", source, stub)
+		Expect(x.Result.Value()).To(Equal(int64(5)))
+		Expect(explanationMessages(x)).To(ContainElement(`merged stub value (test:4:7): 5`))
+		Expect(explanationMessages(x)).To(ContainElement(`decisions: merged`))
+	})
+
+	It("reports merge decisions", func() {
+		source := parseYAML(`
+---
+list:
+  - <<: (( merge on key ))
+  - key: a
+`)
+		stub := parseYAML(`
+---
+list:
+  - key: b
+`)
+		x := explanationFor("list", source, stub)
+		Expect(explanationMessages(x)).To(ContainElement(`merge with stub path list`))
+		Expect(explanationMessages(x)).To(ContainElement(`decisions: list entries merged on key key`))
+	})
+
+	It("reports temporary nodes", func() {
+		source := parseYAML(`
+---
+meta:
+  <<: (( &temporary ))
+  node: 1
+`)
+		x := explanationFor("meta.node", source)
+		Expect(x.Found).To(BeTrue())
+		Expect(x.OmittedBy).To(Equal([]string{"meta"}))
+		Expect(x.String()).To(HaveSuffix("final value: 1\nomitted from the final document by meta\n"))
+	})
+})
@@ -0,0 +1,108 @@
+package flow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/vfs"
+	"github.com/mandelsoft/spiff/yaml"
+)
+
+var _ = Describe("Using a virtual file system", func() {
+	var fs *vfs.MemoryFileSystem
+	var env dynaml.Binding
+
+	BeforeEach(func() {
+		fs = vfs.NewMemoryFileSystem()
+		fs.WriteFile("/data/values.yml", []byte("alice: 25\n"), 0644)
+		fs.WriteFile("/data/text", []byte("some text"), 0644)
+		fs.WriteFile("/data/settings.env", []byte("ALICE=25\n"), 0644)
+		fs.MkdirAll("/data/sub")
+		env = NewEnvironmentWithState(nil, "", NewState("").SetFileSystem(fs))
+	})
+
+	AfterEach(func() {
+		CleanupEnvironment(env)
+	})
+
+	flowWithFS := func(source yaml.Node) yaml.Node {
+		result, err := Cascade(env, source, false)
+		Expect(err).NotTo(HaveOccurred())
+		return result
+	}
+
+	It("reads files", func() {
+		source := parseYAML(`
+---
+yaml: (( read("/data/values.yml") ))
+text: (( read("/data/text") ))
+env: (( read("/data/settings.env", "env") ))
+envtext: (( read("/data/settings.env") ))
+auto:
+  yaml: (( read("/data/values.yml", "auto") ))
+  text: (( read("/data/text", "auto") ))
+  env: (( read("/data/settings.env", "auto") ))
+`)
+		resolved := parseYAML(`
+---
+yaml:
+  alice: 25
+text: some text
+env:
+  ALICE: "25"
+envtext: "ALICE=25\n"
+auto:
+  yaml:
+    alice: 25
+  text: some text
+  env:
+    ALICE: "25"
+`)
+		Expect(flowWithFS(source).EquivalentToNode(resolved)).To(BeTrue())
+	})
+
+	It("writes files", func() {
+		source := parseYAML(`
+---
+file: (( write("/out/file", "data") ))
+`)
+		flowWithFS(source)
+		Expect(fs.ReadFile("/out/file")).To(Equal([]byte("data")))
+	})
+
+	It("lists and looks up files", func() {
+		source := parseYAML(`
+---
+files: (( list_files("/data") ))
+dirs: (( list_dirs("/data") ))
+lookup: (( lookup_file("text", "/other", "/data") ))
+`)
+		resolved := parseYAML(`
+---
+files:
+  - settings.env
+  - text
+  - values.yml
+dirs:
+  - sub
+lookup:
+  - /data/text
+`)
+		Expect(flowWithFS(source).EquivalentToNode(resolved)).To(BeTrue())
+	})
+
+	It("creates temporary files", func() {
+		source := parseYAML(`
+---
+file: (( tempfile("data") ))
+`)
+		result := flowWithFS(source)
+		name, ok := yaml.FindString(result, "file")
+		Expect(ok).To(BeTrue())
+		Expect(fs.ReadFile(name)).To(Equal([]byte("data")))
+
+		CleanupEnvironment(env)
+		Expect(vfs.Exists(fs, name, false)).To(BeFalse())
+	})
+})
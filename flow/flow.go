@@ -32,6 +32,20 @@ func get_inherited_flags(env dynaml.Binding) (yaml.NodeFlags, yaml.Node) {
 }
 
 func flow(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
+	eval := getEvaluation(env)
+	if eval == nil {
+		return flowNode(root, env, shouldOverride)
+	}
+	if eval.unchanged(root, env, shouldOverride) {
+		debug.Debug("skip unchanged node %v\n", env.Path())
+		return root
+	}
+	result := eval.update(root, flowNode(root, env, shouldOverride), env, shouldOverride)
+	explainResult(env, result)
+	return result
+}
+
+func flowNode(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
 	if root == nil {
 		return root
 	}
@@ -48,9 +62,10 @@ func flow(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
 		env = env.RedirectOverwrite(redirect)
 	}
 
-	debug.Debug("//{ FLOW %v: %+v\n", env.Path(), root)
+	debug.Debug("//{ FLOW %v (%s): %+v\n", env.Path(), root.SourceLocation(), root)
 	debug.Debug("/// BIND: %+v\n", env)
 	defer debug.Debug("//}\n")
+	explain(env, "node (%s): %s", root.SourceLocation(), description{root})
 	if !replace {
 		if _, ok := root.Value().(dynaml.Expression); !ok && merged {
 			debug.Debug("  skip handling of merged node")
@@ -85,6 +100,10 @@ func flow(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
 				debug.Debug("  value template %s", val)
 				eval = dynaml.NewTemplateValue(env.Path(), val, root, env)
 			} else {
+				explainReferences(env, val)
+				if m, ok := val.(dynaml.MergeExpr); ok {
+					explainMerge(env, m)
+				}
 				eval, info, ok = val.Evaluate(env, false)
 				if err := info.Cleanup(); err != nil {
 					info.SetError("%s", err)
@@ -101,6 +120,7 @@ func flow(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
 			if !ok {
 				root = yaml.IssueNode(root, true, false, info.Issue)
 				debug.Debug("??? failed ---> KEEP\n")
+				explain(env, "evaluation pending or failed: %s", info.Issue.Issue)
 				if !shouldOverride {
 					return root
 				}
@@ -108,7 +128,7 @@ func flow(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
 				if info.SourceName() != "" {
 					source = info.SourceName()
 				}
-				result := yaml.NewNode(eval, source)
+				result := yaml.PositionedNode(eval, source, root)
 				_, ok = eval.(string)
 				if ok {
 					// map result to potential expression
@@ -154,6 +174,11 @@ func flow(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
 				if (flags | result.Flags()) != result.Flags() {
 					result = yaml.AddFlags(result, flags)
 				}
+				if expr {
+					explain(env, "evaluation pending")
+				} else {
+					explain(env, "evaluates to %s", description{result})
+				}
 				if expr || result.Merged() || !shouldOverride || result.Preferred() {
 					debug.Debug("   prefer expression over override")
 					debug.Debug("??? ---> %+v\n", result)
@@ -179,7 +204,12 @@ func flow(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
 	if !merged && root.StandardOverride() && shouldOverride && !env.NoMerge() {
 		debug.Debug("/// lookup stub %v -> %v\n", env.Path(), env.StubPath())
 		overridden, found := env.FindInStubs(env.StubPath())
+		if found && overridden.Flags().Default() {
+			explain(env, "stub value (%s) is marked as %s, keep template value", overridden.SourceLocation(), dynaml.DEFAULT)
+		}
 		if found && !overridden.Flags().Default() {
+			debug.Debug("/// found in stub %s\n", overridden.SourceLocation())
+			explain(env, "overridden by stub value (%s): %s", overridden.SourceLocation(), description{overridden})
 			root = overridden
 			if keyName != "" {
 				root = yaml.KeyNameNode(root, keyName)
@@ -226,14 +256,23 @@ func flowMap(root yaml.Node, env dynaml.Binding) yaml.Node {
 	issue, failed := root.Issue(), root.Failed()
 	rootMap := root.Value().(map[string]yaml.Node)
 
+	// evaluated fields are immediately visible for the
+	// evaluation of the other fields of the map
+	live := make(map[string]yaml.Node, len(rootMap))
+	for k, v := range rootMap {
+		live[k] = v
+	}
 	rootEnv := env
-	env = env.WithScope(rootMap)
+	env = env.WithScope(live)
 
 	redirect := root.RedirectPath()
 	replace := root.ReplaceFlag()
 	newMap := make(map[string]yaml.Node)
 
 	sortedKeys := getSortedKeys(rootMap)
+	if eval := getEvaluation(env); eval != nil {
+		sortedKeys = eval.deps.order(env.Path(), sortedKeys)
+	}
 
 	debug.Debug("HANDLE MAP %v\n", env.Path())
 	mergefound := false
@@ -249,7 +288,7 @@ func flowMap(root yaml.Node, env dynaml.Binding) yaml.Node {
 			}
 			mergefound = true
 			_, initial := val.Value().(string)
-			base := flow(val, env, false)
+			base := flowNode(val, env, false)
 			if base.Undefined() {
 				return yaml.UndefinedNode(root)
 			}
@@ -335,6 +374,9 @@ func flowMap(root yaml.Node, env dynaml.Binding) yaml.Node {
 				val = yaml.AddFlags(val, yaml.FLAG_IMPLIED)
 			}
 			newMap[key] = val
+			live[key] = val
+		} else {
+			delete(live, key)
 		}
 	}
 
@@ -356,7 +398,7 @@ func flowMap(root yaml.Node, env dynaml.Binding) yaml.Node {
 	var result interface{}
 	if template {
 		debug.Debug(" as template\n")
-		result = dynaml.NewTemplateValue(env.Path(), yaml.NewNode(newMap, root.SourceName()), root, rootEnv)
+		result = dynaml.NewTemplateValue(env.Path(), yaml.PositionedNode(newMap, root.SourceName(), root), root, rootEnv)
 	} else {
 		result = newMap
 	}
@@ -474,7 +516,7 @@ func stepName(index int, value yaml.Node, keyName string, env dynaml.Binding) (s
 		debug.Debug("found raw %s", keyName)
 		_, ok := v.Value().(dynaml.Expression)
 		if ok {
-			v = flow(v, env.WithPath(step), false)
+			v = flowNode(v, env.WithPath(step), false)
 			_, ok := v.Value().(dynaml.Expression)
 			if ok {
 				return step, false
@@ -511,7 +553,7 @@ func processMerges(orig yaml.Node, root []yaml.Node, env dynaml.Binding) (interf
 		if ok {
 			debug.Debug("*** %+v\n", inlineNode.Value())
 			_, initial := inlineNode.Value().(string)
-			result := flow(inlineNode, env, false)
+			result := flowNode(inlineNode, env, false)
 			if result.KeyName() != "" {
 				keyName = result.KeyName()
 			}
@@ -577,7 +619,7 @@ func processMerges(orig yaml.Node, root []yaml.Node, env dynaml.Binding) (interf
 	var result interface{}
 	if template {
 		debug.Debug(" as template\n")
-		result = dynaml.NewTemplateValue(env.Path(), yaml.NewNode(spliced, orig.SourceName()), orig, env)
+		result = dynaml.NewTemplateValue(env.Path(), yaml.PositionedNode(spliced, orig.SourceName(), orig), orig, env)
 	} else {
 		result = spliced
 	}
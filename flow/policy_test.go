@@ -0,0 +1,116 @@
+package flow
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/vfs"
+)
+
+var _ = Describe("Flowing with a policy", func() {
+	var env dynaml.Binding
+
+	BeforeEach(func() {
+		fs := vfs.NewMemoryFileSystem()
+		fs.WriteFile("/allowed/file", []byte("allowed"), 0644)
+		fs.WriteFile("/denied/file", []byte("denied"), 0644)
+		policy := &dynaml.Policy{Directories: []string{"/allowed", "/tmp"}}
+		env = NewEnvironmentWithState(nil, "", NewState("").SetFileSystem(fs).SetPolicy(policy))
+	})
+
+	AfterEach(func() {
+		CleanupEnvironment(env)
+	})
+
+	It("grants allowed access", func() {
+		source := parseYAML(`
+---
+file: (( read("/allowed/file") ))
+temp: (( tempfile("data") ))
+`)
+		_, err := Cascade(env, source, false)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("denies other access", func() {
+		source := parseYAML(`
+---
+file: (( read("/denied/file") ))
+`)
+		_, err := Cascade(env, source, false)
+		Expect(err).To(MatchError(ContainSubstring(`read: path "/denied/file" denied by policy`)))
+	})
+
+	It("omits the state environment from the context", func() {
+		source := parseYAML(`
+---
+outer: (( defined(__ctx.OUTER) ))
+`)
+		result, err := Cascade(env, source, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.EquivalentToNode(parseYAML(`
+---
+outer: false
+`))).To(BeTrue())
+	})
+
+	Context("reading URLs", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/allowed/file", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("allowed"))
+			})
+			mux.HandleFunc("/allowed/redirect", func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/denied/file", http.StatusFound)
+			})
+			mux.HandleFunc("/denied/file", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("denied"))
+			})
+			server = httptest.NewServer(mux)
+			policy := &dynaml.Policy{URLs: []string{server.URL + "/allowed"}}
+			env = NewEnvironmentWithState(nil, "", NewState("").SetPolicy(policy))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("grants allowed URLs", func() {
+			source := parseYAML(fmt.Sprintf(`
+---
+file: (( read("%s/allowed/file", "text") ))
+`, server.URL))
+			result, err := Cascade(env, source, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.EquivalentToNode(parseYAML(`
+---
+file: allowed
+`))).To(BeTrue())
+		})
+
+		It("denies redirects to other URLs", func() {
+			source := parseYAML(fmt.Sprintf(`
+---
+file: (( read("%s/allowed/redirect", "text") ))
+`, server.URL))
+			_, err := Cascade(env, source, false)
+			Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf(`URL "%s/denied/file" denied by policy`, server.URL))))
+		})
+
+		It("denies URLs with user info", func() {
+			source := parseYAML(fmt.Sprintf(`
+---
+file: (( read("http://allowed@%s/allowed/file", "text") ))
+`, server.Listener.Addr()))
+			_, err := Cascade(env, source, false)
+			Expect(err).To(MatchError(ContainSubstring(`denied by policy`)))
+		})
+	})
+})
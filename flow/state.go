@@ -3,23 +3,64 @@ package flow
 import (
 	"crypto/sha512"
 	"encoding/base64"
+	"errors"
 	"fmt"
-	"github.com/mandelsoft/spiff/debug"
 	"io/ioutil"
 	"net/http"
-	"os"
 	"path"
 	"strings"
+
+	"github.com/mandelsoft/spiff/debug"
+	"github.com/mandelsoft/spiff/dynaml"
+	"github.com/mandelsoft/spiff/dynaml/passwd"
+	"github.com/mandelsoft/spiff/vfs"
 )
 
 type State struct {
-	files     map[string]string // content hash to temp file name
-	fileCache map[string][]byte // file content cache
-	key       string            // default encryption key
+	files      map[string]string // content hash to temp file name
+	fileCache  map[string][]byte // file content cache
+	key        string            // default encryption key
+	fileSystem vfs.FileSystem    // file system used for all file operations
+	policy     *dynaml.Policy    // access restrictions, nil for unrestricted access
 }
 
 func NewState(key string) *State {
-	return &State{map[string]string{}, map[string][]byte{}, key}
+	return &State{map[string]string{}, map[string][]byte{}, key, vfs.OSFileSystem, nil}
+}
+
+// SetFileSystem replaces the file system used by the state,
+// for example by an in-memory file system to run hermetic merges.
+func (s *State) SetFileSystem(fs vfs.FileSystem) *State {
+	if fs == nil {
+		fs = vfs.OSFileSystem
+	}
+	s.fileSystem = fs
+	return s
+}
+
+func (s *State) GetFileSystem() vfs.FileSystem {
+	return s.fileSystem
+}
+
+// SetPolicy restricts the access to commands, files, URLs and
+// environment variables. A nil policy grants unrestricted access.
+func (s *State) SetPolicy(policy *dynaml.Policy) *State {
+	s.policy = policy
+	return s
+}
+
+func (s *State) GetPolicy() *dynaml.Policy {
+	return s.policy
+}
+
+// DefaultEncryptionKey provides the encryption key configured by the
+// environment variables SPIFF_ENCRYPTION_KEY or SPIFF_ENCRYPTION_KEY_FILE.
+func DefaultEncryptionKey() string {
+	key, err := passwd.EnvironmentKey()
+	if err != nil {
+		debug.Debug("cannot determine encryption key: %s\n", err)
+	}
+	return key
 }
 
 func (s *State) GetEncryptionKey() string {
@@ -32,11 +73,15 @@ func (s *State) GetTempName(data []byte) (string, error) {
 
 	name, ok := s.files[hash]
 	if !ok {
-		file, err := ioutil.TempFile("", "spiff-")
+		file, err := s.fileSystem.TempFile("spiff-")
 		if err != nil {
 			return "", err
 		}
-		name = file.Name()
+		if err := s.policy.CheckFile(s.fileSystem, file); err != nil {
+			s.fileSystem.Remove(file)
+			return "", err
+		}
+		name = file
 		s.files[hash] = name
 	}
 	return name, nil
@@ -44,11 +89,24 @@ func (s *State) GetTempName(data []byte) (string, error) {
 
 func (s *State) Cleanup() {
 	for _, n := range s.files {
-		os.Remove(n)
+		s.fileSystem.Remove(n)
 	}
 	s.files = map[string]string{}
 }
 
+// httpClient provides a client checking every redirect
+// against the policy of the state.
+func (s *State) httpClient() *http.Client {
+	return &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return s.policy.CheckURL(req.URL.String())
+		},
+	}
+}
+
 func (s *State) GetFileContent(file string, cached bool) ([]byte, error) {
 	var err error
 
@@ -56,7 +114,10 @@ func (s *State) GetFileContent(file string, cached bool) ([]byte, error) {
 	if !cached || data == nil {
 		debug.Debug("reading file %s\n", file)
 		if strings.HasPrefix(file, "http:") || strings.HasPrefix(file, "https:") {
-			response, err := http.Get(file)
+			if err := s.policy.CheckURL(file); err != nil {
+				return nil, err
+			}
+			response, err := s.httpClient().Get(file)
 			if err != nil {
 				return nil, fmt.Errorf("error getting [%s]: %s", file, err)
 			} else {
@@ -68,7 +129,10 @@ func (s *State) GetFileContent(file string, cached bool) ([]byte, error) {
 				data = contents
 			}
 		} else {
-			data, err = ioutil.ReadFile(file)
+			if err := s.policy.CheckFile(s.fileSystem, file); err != nil {
+				return nil, err
+			}
+			data, err = s.fileSystem.ReadFile(file)
 			if err != nil {
 				return nil, fmt.Errorf("error reading [%s]: %s", path.Clean(file), err)
 			}
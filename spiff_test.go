@@ -1,9 +1,12 @@
 package main
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -53,5 +56,565 @@ foo: bar
 				Expect(merge.Out).To(Say(`foo: bar`))
 			})
 		})
+
+		Context("when nodes cannot be resolved", func() {
+			var template *os.File
+
+			run := func(source string, args ...string) {
+				var err error
+
+				template, err = ioutil.TempFile(os.TempDir(), "unresolved.yml")
+				Expect(err).NotTo(HaveOccurred())
+				template.Write([]byte(source))
+				args = append(append([]string{"merge"}, args...), template.Name())
+				merge, err = Start(exec.Command(spiff, args...), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			AfterEach(func() {
+				os.Remove(template.Name())
+			})
+
+			It("exits with the failed code for errors", func() {
+				run(`
+---
+foo: (( bar ))
+`)
+				Expect(merge.Wait()).To(Exit(3))
+				Expect(merge.Err).To(Say(`\(\( bar \)\)\tin .*unresolved.yml[0-9]*:3:6\tfoo\t\(\)\t\*'bar' not found`))
+			})
+
+			It("exits with the unresolved code for cycles", func() {
+				run(`
+---
+foo: (( bar ))
+bar: (( foo ))
+`)
+				Expect(merge.Wait()).To(Exit(2))
+			})
+
+			It("reports unresolved nodes as json", func() {
+				run(`
+---
+foo:
+  bar: (( 1 + true ))
+`, "--errors-json")
+				Expect(merge.Wait()).To(Exit(3))
+				var report []map[string]interface{}
+				Expect(json.Unmarshal(merge.Err.Contents(), &report)).To(Succeed())
+				Expect(report).To(HaveLen(1))
+				Expect(report[0]["path"]).To(Equal("foo.bar"))
+				Expect(report[0]["context"]).To(Equal([]interface{}{"foo", "bar"}))
+				Expect(report[0]["source"]).To(Equal(template.Name()))
+				Expect(report[0]["line"]).To(Equal(4.0))
+				Expect(report[0]["column"]).To(Equal(8.0))
+				Expect(report[0]["expression"]).To(Equal("(( 1 + true ))"))
+				Expect(report[0]["classification"]).To(Equal("local"))
+				Expect(report[0]["issue"]).To(Equal("integer operand required"))
+			})
+
+			It("reports dependent nodes once ordered by path", func() {
+				run(`
+---
+c: (( b ))
+a: (( foo(1) ))
+b: (( a ))
+`, "--errors-json")
+				Expect(merge.Wait()).To(Exit(3))
+				var report []map[string]interface{}
+				Expect(json.Unmarshal(merge.Err.Contents(), &report)).To(Succeed())
+				paths := []interface{}{}
+				for _, n := range report {
+					paths = append(paths, n["path"])
+				}
+				Expect(paths).To(Equal([]interface{}{"a", "b", "c"}))
+				Expect(report[1]["classification"]).To(Equal("dependent"))
+			})
+		})
+
+		Context("when running in sandbox mode", func() {
+			var template *os.File
+
+			run := func(source string, args ...string) {
+				var err error
+
+				template, err = ioutil.TempFile(os.TempDir(), "sandbox.yml")
+				Expect(err).NotTo(HaveOccurred())
+				template.Write([]byte(source))
+				args = append(append([]string{"merge"}, args...), template.Name())
+				merge, err = Start(exec.Command(spiff, args...), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			AfterEach(func() {
+				os.Remove(template.Name())
+			})
+
+			It("denies command execution", func() {
+				run(`
+---
+foo: (( exec("echo", "bar") ))
+`, "--sandbox")
+				Expect(merge.Wait()).To(Exit(3))
+				Expect(merge.Err).To(Say(`exec: command "echo" denied by policy`))
+			})
+
+			It("executes allowed commands", func() {
+				run(`
+---
+foo: (( exec("echo", "bar") ))
+`, "--allow-command", "echo")
+				Expect(merge.Wait()).To(Exit(0))
+				Expect(merge.Out).To(Say(`foo: bar`))
+			})
+		})
+
+		Context("when selecting an output format", func() {
+			var template *os.File
+
+			run := func(source string, args ...string) {
+				var err error
+
+				template, err = ioutil.TempFile(os.TempDir(), "format.yml")
+				Expect(err).NotTo(HaveOccurred())
+				template.Write([]byte(source))
+				args = append(append([]string{"merge"}, args...), template.Name())
+				merge, err = Start(exec.Command(spiff, args...), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			AfterEach(func() {
+				os.Remove(template.Name())
+			})
+
+			It("prints toml", func() {
+				run(`
+---
+server:
+  port: (( 8000 + 80 ))
+`, "--format", "toml")
+				Expect(merge.Wait()).To(Exit(0))
+				Expect(string(merge.Out.Contents())).To(Equal("\n[server]\nport = 8080\n"))
+			})
+
+			It("prints export statements", func() {
+				run(`
+---
+db:
+  host: localhost
+`, "--format", "env")
+				Expect(merge.Wait()).To(Exit(0))
+				Expect(string(merge.Out.Contents())).To(Equal("export DB_HOST='localhost'\n"))
+			})
+
+			It("rejects unknown formats", func() {
+				run("foo: bar\n", "--format", "ini")
+				Expect(merge.Wait()).To(Exit(1))
+				Expect(merge.Err).To(Say(`invalid output format "ini"`))
+			})
+		})
+
+		Context("when watching files", func() {
+			var dir string
+
+			BeforeEach(func() {
+				var err error
+				dir, err = ioutil.TempDir(os.TempDir(), "watch")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				merge.Kill()
+				os.RemoveAll(dir)
+			})
+
+			It("merges again when a read file changes", func() {
+				values := filepath.Join(dir, "values.yml")
+				template := filepath.Join(dir, "template.yml")
+				Expect(ioutil.WriteFile(values, []byte("a: 1\n"), 0644)).To(Succeed())
+				Expect(ioutil.WriteFile(template, []byte(`v: (( read("`+values+`").a ))`), 0644)).To(Succeed())
+
+				var err error
+				merge, err = Start(exec.Command(spiff, "merge", "--watch", "--watch-interval", "50ms", template), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(merge.Err).Should(Say("watching 2 files"))
+				Expect(merge.Out).To(Say("v: 1"))
+
+				Expect(ioutil.WriteFile(values, []byte("a: (( b ))\n"), 0644)).To(Succeed())
+				Eventually(merge.Err).Should(Say("merge failed with exit code 3"))
+
+				Expect(ioutil.WriteFile(values, []byte("a: 2\n"), 0644)).To(Succeed())
+				Eventually(merge.Out).Should(Say("v: 2"))
+			})
+		})
+
+		Context("when writing files", func() {
+			var template *os.File
+			var dir string
+
+			run := func(source string, args ...string) {
+				var err error
+
+				template, err = ioutil.TempFile(os.TempDir(), "split.yml")
+				Expect(err).NotTo(HaveOccurred())
+				template.Write([]byte(source))
+				args = append(append([]string{"merge", "--output-dir", dir}, args...), template.Name())
+				merge, err = Start(exec.Command(spiff, args...), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			BeforeEach(func() {
+				var err error
+				dir, err = ioutil.TempDir(os.TempDir(), "split")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				os.Remove(template.Name())
+				os.RemoveAll(dir)
+			})
+
+			resources := `
+---
+- kind: Service
+  metadata:
+    name: web
+- kind: Deployment
+  metadata:
+    name: web
+`
+
+			It("names files by an expression", func() {
+				run(resources, "--split", "--file-name", `(( lower(kind) "-" metadata.name ".yaml" ))`)
+				Expect(merge.Wait()).To(Exit(0))
+				Expect(ioutil.ReadFile(filepath.Join(dir, "service-web.yaml"))).To(Equal([]byte("kind: Service\nmetadata:\n  name: web\n")))
+				Expect(filepath.Join(dir, "deployment-web.yaml")).To(BeARegularFile())
+			})
+
+			It("numbers files without expression", func() {
+				run(resources, "--split")
+				Expect(merge.Wait()).To(Exit(0))
+				Expect(filepath.Join(dir, "1.yaml")).To(BeARegularFile())
+				Expect(filepath.Join(dir, "2.yaml")).To(BeARegularFile())
+			})
+
+			It("prunes files of previous merges only", func() {
+				Expect(ioutil.WriteFile(filepath.Join(dir, "other.yaml"), []byte("other"), 0644)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(dir, ".git"), 0755)).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(dir, ".git", "config"), []byte("config"), 0644)).To(Succeed())
+				run(resources, "--split", "--prune")
+				Expect(merge.Wait()).To(Exit(0))
+				Expect(filepath.Join(dir, "1.yaml")).To(BeARegularFile())
+				Expect(filepath.Join(dir, "other.yaml")).To(BeARegularFile())
+				os.Remove(template.Name())
+
+				run(resources, "--split", "--prune", "--file-name", `(( lower(kind) "-" metadata.name ".yaml" ))`)
+				Expect(merge.Wait()).To(Exit(0))
+				Expect(filepath.Join(dir, "service-web.yaml")).To(BeARegularFile())
+				Expect(filepath.Join(dir, "1.yaml")).NotTo(BeAnExistingFile())
+				Expect(filepath.Join(dir, "2.yaml")).NotTo(BeAnExistingFile())
+				Expect(filepath.Join(dir, "other.yaml")).To(BeARegularFile())
+				Expect(filepath.Join(dir, ".git", "config")).To(BeARegularFile())
+				Expect(ioutil.ReadFile(filepath.Join(dir, ".spiff-generated"))).To(Equal([]byte("deployment-web.yaml\nservice-web.yaml\n")))
+			})
+
+			It("keeps files of previous merges without prune", func() {
+				run(resources, "--split")
+				Expect(merge.Wait()).To(Exit(0))
+				os.Remove(template.Name())
+
+				run(resources, "--split", "--file-name", `(( lower(kind) "-" metadata.name ".yaml" ))`)
+				Expect(merge.Wait()).To(Exit(0))
+				Expect(filepath.Join(dir, "1.yaml")).To(BeARegularFile())
+				Expect(filepath.Join(dir, "service-web.yaml")).To(BeARegularFile())
+			})
+
+			It("rejects duplicate file names", func() {
+				run(resources, "--split", "--file-name", `(( metadata.name ))`)
+				Expect(merge.Wait()).To(Exit(1))
+				Expect(merge.Err).To(Say(`document 2: duplicate file name "web"`))
+				Expect(filepath.Join(dir, "web")).NotTo(BeAnExistingFile())
+			})
+		})
+	})
+
+	Describe("repl", func() {
+		var repl *Session
+		var template string
+		var content string
+
+		BeforeEach(func() {
+			content = "list: [ 1, 2, 3 ]\ninc: (( |x|->x + 1 ))\n"
+		})
+
+		run := func(input string, args ...string) {
+			file, err := ioutil.TempFile(os.TempDir(), "repl.yml")
+			Expect(err).NotTo(HaveOccurred())
+			file.Write([]byte(content))
+			file.Close()
+			template = file.Name()
+
+			cmd := exec.Command(spiff, append(append([]string{"repl"}, args...), template)...)
+			cmd.Stdin = strings.NewReader(input)
+			repl, err = Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		AfterEach(func() {
+			os.Remove(template)
+		})
+
+		It("evaluates expressions with the template as binding", func() {
+			run("sum[list|0|s,x|->s + x]\n(( map[list|x|->.inc(x)] ))\nunknown\n")
+			Expect(repl.Wait()).To(Exit(0))
+			Expect(string(repl.Out.Contents())).To(Equal("6\n- 2\n- 3\n- 4\nerror: 'unknown' not found\n"))
+		})
+
+		It("adds stub content", func() {
+			run(":set values.port 8080\nvalues.port + list.[0]\n!2\n:history\n")
+			Expect(repl.Wait()).To(Exit(0))
+			Expect(string(repl.Out.Contents())).To(Equal("8081\nvalues.port + list.[0]\n8081\n" +
+				"   1  :set values.port 8080\n   2  values.port + list.[0]\n   3  values.port + list.[0]\n"))
+		})
+
+		It("continues with a partial binding", func() {
+			content = "a: 1\nb: (( unknown ))\n"
+			run("a + 1\nb\n")
+			Expect(repl.Wait()).To(Exit(0))
+			Expect(repl.Out).To(Say("warning: binding incomplete: unresolved nodes:"))
+			Expect(repl.Out).To(Say(`\(\( unknown \)\)`))
+			Expect(repl.Out).To(Say("\n2\n"))
+			Expect(repl.Out).To(Say("error: "))
+		})
+	})
+
+	Describe("encrypt", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "encrypt")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "key"), []byte("secret\n"), 0600)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "data.yml"), []byte("alice: 25\n"), 0644)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("keeps the key file for an empty password", func() {
+			key := filepath.Join(dir, "key")
+			encrypt, err := Start(exec.Command(spiff, "encrypt", "--key-file", key, filepath.Join(dir, "data.yml"), "", "AES256GCM"), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(encrypt.Wait()).To(Exit(0))
+			Expect(ioutil.WriteFile(filepath.Join(dir, "data.enc"), encrypt.Out.Contents(), 0644)).To(Succeed())
+
+			decrypt, err := Start(exec.Command(spiff, "encrypt", "-d", filepath.Join(dir, "data.enc"), "secret"), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decrypt.Wait()).To(Exit(0))
+			Expect(decrypt.Out).To(Say("alice: 25"))
+		})
+	})
+
+	Describe("explain", func() {
+		var explain *Session
+		var template *os.File
+
+		run := func(path string, source string) {
+			var err error
+			template, err = ioutil.TempFile(os.TempDir(), "explain.yml")
+			Expect(err).NotTo(HaveOccurred())
+			template.Write([]byte(source))
+			template.Close()
+			explain, err = Start(exec.Command(spiff, "explain", path, template.Name()), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		AfterEach(func() {
+			os.Remove(template.Name())
+		})
+
+		It("explains the evaluation of a node", func() {
+			run("foo", "foo: (( 1 + 1 ))\n")
+			Expect(explain.Wait()).To(Exit(0))
+			Expect(explain.Out).To(Say("evaluates to 2"))
+		})
+
+		It("fails for unknown paths", func() {
+			run("nope", "foo: bar\n")
+			Expect(explain.Wait()).To(Exit(1))
+			Expect(explain.Err).To(Say(`path "nope" not found`))
+		})
+	})
+
+	Describe("diff", func() {
+		var diff *Session
+		var files []string
+
+		run := func(a, b string, args ...string) {
+			files = nil
+			for _, content := range []string{a, b} {
+				file, err := ioutil.TempFile(os.TempDir(), "diff.yml")
+				Expect(err).NotTo(HaveOccurred())
+				file.Write([]byte(content))
+				file.Close()
+				files = append(files, file.Name())
+			}
+			var err error
+			args = append(append([]string{"diff"}, args...), files...)
+			diff, err = Start(exec.Command(spiff, args...), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		AfterEach(func() {
+			for _, f := range files {
+				os.Remove(f)
+			}
+		})
+
+		It("exits with zero for equal documents", func() {
+			run("foo: bar\n", "foo: bar\n")
+			Expect(diff.Wait()).To(Exit(0))
+			Expect(diff.Out).To(Say("no differences!"))
+		})
+
+		It("exits with one for differences", func() {
+			run("foo: bar\n", "foo: baz\n", "--format", "plain")
+			Expect(diff.Wait()).To(Exit(1))
+			Expect(diff.Out).To(Say("Difference in  foo\n"))
+			Expect(string(diff.Out.Contents())).NotTo(ContainSubstring("\x1b["))
+		})
+
+		It("reports differences as json", func() {
+			run("foo: bar\nold: 1\n", "foo: baz\n", "--format", "json")
+			Expect(diff.Wait()).To(Exit(1))
+			var records []map[string]interface{}
+			Expect(json.Unmarshal(diff.Out.Contents(), &records)).To(Succeed())
+			Expect(records).To(ConsistOf(
+				map[string]interface{}{"path": "foo", "op": "replace", "old": "bar", "new": "baz"},
+				map[string]interface{}{"path": "old", "op": "remove", "old": 1.0},
+			))
+		})
+
+		It("reports differences as json patch", func() {
+			run("list:\n- name: a\n- name: b\n", "list:\n- name: b\n- name: c\n", "--format", "jsonpatch")
+			Expect(diff.Wait()).To(Exit(1))
+			var patch []map[string]interface{}
+			Expect(json.Unmarshal(diff.Out.Contents(), &patch)).To(Succeed())
+			Expect(patch).To(Equal([]map[string]interface{}{
+				{"op": "add", "path": "/list/-", "value": map[string]interface{}{"name": "c"}},
+				{"op": "test", "path": "/list/0", "value": map[string]interface{}{"name": "a"}},
+				{"op": "remove", "path": "/list/0"},
+			}))
+		})
+
+		It("filters differences by path patterns", func() {
+			ignore, err := ioutil.TempFile(os.TempDir(), "spiffignore")
+			Expect(err).NotTo(HaveOccurred())
+			ignore.Write([]byte("# generated\nstatus\n"))
+			ignore.Close()
+			defer os.Remove(ignore.Name())
+
+			run("foo: bar\nstatus: 1\nmeta:\n  id: 1\n", "foo: baz\nstatus: 2\nmeta:\n  id: 2\n",
+				"--format", "plain", "--ignore", "meta.*", "--ignore-file", ignore.Name())
+			Expect(diff.Wait()).To(Exit(1))
+			Expect(diff.Out).To(Say("Difference in  foo\n"))
+			Expect(string(diff.Out.Contents())).NotTo(ContainSubstring("status"))
+			Expect(string(diff.Out.Contents())).NotTo(ContainSubstring("meta"))
+		})
+
+		It("exits with two for errors", func() {
+			run("foo: bar\n", "foo: [\n")
+			Expect(diff.Wait()).To(Exit(2))
+			Expect(diff.Err).To(Say("error parsing b"))
+		})
+
+		It("exits with zero if only unselected paths differ", func() {
+			run("foo: bar\nstatus: 1\n", "foo: bar\nstatus: 2\n", "--only", "foo")
+			Expect(diff.Wait()).To(Exit(0))
+			Expect(diff.Out).To(Say("no differences!"))
+		})
+	})
+
+	Describe("patch", func() {
+		var patch *Session
+		var files []string
+
+		run := func(doc, p string) {
+			files = nil
+			for _, content := range []string{doc, p} {
+				file, err := ioutil.TempFile(os.TempDir(), "patch.yml")
+				Expect(err).NotTo(HaveOccurred())
+				file.Write([]byte(content))
+				file.Close()
+				files = append(files, file.Name())
+			}
+			var err error
+			patch, err = Start(exec.Command(spiff, append([]string{"patch"}, files...)...), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		AfterEach(func() {
+			for _, f := range files {
+				os.Remove(f)
+			}
+		})
+
+		It("applies patches to document streams", func() {
+			run("---\nfoo: bar\n---\nfoo: bar\n", `[[], [{"op": "replace", "path": "/foo", "value": "baz"}]]`)
+			Expect(patch.Wait()).To(Exit(0))
+			Expect(patch.Out).To(Say("---\nfoo: bar\n---\nfoo: baz\n"))
+		})
+
+		It("reports conflicts", func() {
+			run("foo: bar\n", `[{"op": "replace", "path": "foo", "old": "other", "new": "baz"}]`)
+			Expect(patch.Wait()).To(Exit(1))
+			Expect(patch.Err).To(Say(`replace foo: expected value "other", but found "bar"`))
+		})
+	})
+
+	Describe("merge3", func() {
+		var merge3 *Session
+		var files []string
+
+		run := func(docs ...string) {
+			files = nil
+			for _, content := range docs {
+				file, err := ioutil.TempFile(os.TempDir(), "merge3.yml")
+				Expect(err).NotTo(HaveOccurred())
+				file.Write([]byte(content))
+				file.Close()
+				files = append(files, file.Name())
+			}
+			var err error
+			merge3, err = Start(exec.Command(spiff, append([]string{"merge3"}, files...)...), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		AfterEach(func() {
+			for _, f := range files {
+				os.Remove(f)
+			}
+		})
+
+		It("merges changes of both documents", func() {
+			run("a: 1\nb: 1\n", "a: 2\nb: 1\n", "a: 1\nb: 2\n")
+			Expect(merge3.Wait()).To(Exit(0))
+			Expect(merge3.Out).To(Say("a: 2\nb: 2\n"))
+		})
+
+		It("reports conflicts", func() {
+			run("a: 1\n", "a: 2\n", "a: 3\n")
+			Expect(merge3.Wait()).To(Exit(1))
+			Expect(merge3.Out).To(Say("a: 2\n"))
+			Expect(merge3.Err).To(Say("conflict in a: base 1, ours 2, theirs 3"))
+		})
+
+		It("exits with two for errors", func() {
+			run("a: 1\n", "a: 2\n---\na: 3\n", "a: 1\n")
+			Expect(merge3.Wait()).To(Exit(2))
+			Expect(merge3.Err).To(Say("different number of documents"))
+		})
 	})
 })
@@ -0,0 +1,181 @@
+package vfs
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryFileSystem is a file system keeping a file tree in memory.
+// Relative file names are resolved relative to the root directory.
+// Parent directories are created implicitly when writing a file.
+type MemoryFileSystem struct {
+	lock  sync.Mutex
+	files map[string]*memoryFile
+	temp  int
+}
+
+type memoryFile struct {
+	name    string
+	data    []byte
+	mode    os.FileMode
+	modTime time.Time
+}
+
+var _ FileSystem = &MemoryFileSystem{}
+
+// memoryTempDir is the directory used for temporary files
+const memoryTempDir = "/tmp"
+
+func NewMemoryFileSystem() *MemoryFileSystem {
+	fs := &MemoryFileSystem{files: map[string]*memoryFile{}}
+	fs.files["/"] = &memoryFile{name: "/", mode: os.ModeDir | 0755, modTime: time.Now()}
+	return fs
+}
+
+func clean(name string) string {
+	return path.Clean("/" + name)
+}
+
+func notExist(op, name string) error {
+	return &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+}
+
+func (fs *MemoryFileSystem) ReadFile(name string) ([]byte, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	f := fs.files[clean(name)]
+	if f == nil {
+		return nil, notExist("open", name)
+	}
+	if f.mode.IsDir() {
+		return nil, &os.PathError{Op: "read", Path: name, Err: fmt.Errorf("is a directory")}
+	}
+	return append([]byte{}, f.data...), nil
+}
+
+func (fs *MemoryFileSystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	p := clean(name)
+	if f := fs.files[p]; f != nil && f.mode.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: fmt.Errorf("is a directory")}
+	}
+	if err := fs.mkdirAll(path.Dir(p)); err != nil {
+		return &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	fs.files[p] = &memoryFile{name: path.Base(p), data: append([]byte{}, data...), mode: perm & os.ModePerm, modTime: time.Now()}
+	return nil
+}
+
+// MkdirAll creates a directory and all required parent directories.
+func (fs *MemoryFileSystem) MkdirAll(name string) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	return fs.mkdirAll(clean(name))
+}
+
+func (fs *MemoryFileSystem) mkdirAll(p string) error {
+	if f := fs.files[p]; f != nil {
+		if !f.mode.IsDir() {
+			return fmt.Errorf("%s is no directory", p)
+		}
+		return nil
+	}
+	if err := fs.mkdirAll(path.Dir(p)); err != nil {
+		return err
+	}
+	fs.files[p] = &memoryFile{name: path.Base(p), mode: os.ModeDir | 0755, modTime: time.Now()}
+	return nil
+}
+
+func (fs *MemoryFileSystem) Stat(name string) (os.FileInfo, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	f := fs.files[clean(name)]
+	if f == nil {
+		return nil, notExist("stat", name)
+	}
+	return fileInfo{f}, nil
+}
+
+func (fs *MemoryFileSystem) ReadDir(name string) ([]os.FileInfo, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	p := clean(name)
+	f := fs.files[p]
+	if f == nil {
+		return nil, notExist("open", name)
+	}
+	if !f.mode.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: name, Err: fmt.Errorf("not a directory")}
+	}
+	prefix := p
+	if prefix != "/" {
+		prefix += "/"
+	}
+	result := []os.FileInfo{}
+	for n, f := range fs.files {
+		if n != p && strings.HasPrefix(n, prefix) && !strings.Contains(n[len(prefix):], "/") {
+			result = append(result, fileInfo{f})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (fs *MemoryFileSystem) Remove(name string) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	p := clean(name)
+	f := fs.files[p]
+	if f == nil {
+		return notExist("remove", name)
+	}
+	if f.mode.IsDir() {
+		for n := range fs.files {
+			if strings.HasPrefix(n, p+"/") {
+				return &os.PathError{Op: "remove", Path: name, Err: fmt.Errorf("directory not empty")}
+			}
+		}
+	}
+	delete(fs.files, p)
+	return nil
+}
+
+func (fs *MemoryFileSystem) TempFile(prefix string) (string, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if err := fs.mkdirAll(memoryTempDir); err != nil {
+		return "", err
+	}
+	for {
+		fs.temp++
+		name := path.Join(memoryTempDir, fmt.Sprintf("%s%d", prefix, fs.temp))
+		if fs.files[name] == nil {
+			fs.files[name] = &memoryFile{name: path.Base(name), mode: 0600, modTime: time.Now()}
+			return name, nil
+		}
+	}
+}
+
+type fileInfo struct {
+	file *memoryFile
+}
+
+func (i fileInfo) Name() string       { return i.file.name }
+func (i fileInfo) Size() int64        { return int64(len(i.file.data)) }
+func (i fileInfo) Mode() os.FileMode  { return i.file.mode }
+func (i fileInfo) ModTime() time.Time { return i.file.modTime }
+func (i fileInfo) IsDir() bool        { return i.file.mode.IsDir() }
+func (i fileInfo) Sys() interface{}   { return nil }
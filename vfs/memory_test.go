@@ -0,0 +1,111 @@
+package vfs_test
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/mandelsoft/spiff/vfs"
+)
+
+func names(infos []os.FileInfo) []string {
+	result := []string{}
+	for _, i := range infos {
+		result = append(result, i.Name())
+	}
+	return result
+}
+
+var _ = Describe("Memory file system", func() {
+	var fs *MemoryFileSystem
+
+	BeforeEach(func() {
+		fs = NewMemoryFileSystem()
+		Expect(fs.WriteFile("/a/b/file", []byte("data"), 0600)).To(Succeed())
+	})
+
+	It("reads written files", func() {
+		Expect(fs.ReadFile("/a/b/file")).To(Equal([]byte("data")))
+		Expect(fs.ReadFile("a/b/../b/file")).To(Equal([]byte("data")))
+	})
+
+	It("creates parent directories", func() {
+		Expect(Exists(fs, "/a/b", true)).To(BeTrue())
+		Expect(Exists(fs, "/a/b/file", false)).To(BeTrue())
+		info, err := fs.Stat("/a/b/file")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode()).To(Equal(os.FileMode(0600)))
+		Expect(info.Size()).To(Equal(int64(4)))
+	})
+
+	It("reports missing files", func() {
+		_, err := fs.ReadFile("/missing")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("lists directories", func() {
+		Expect(fs.WriteFile("/a/other", nil, 0644)).To(Succeed())
+		infos, err := fs.ReadDir("/a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(infos)).To(Equal([]string{"b", "other"}))
+	})
+
+	It("removes files", func() {
+		Expect(fs.Remove("/a/b")).NotTo(Succeed())
+		Expect(fs.Remove("/a/b/file")).To(Succeed())
+		Expect(Exists(fs, "/a/b/file", false)).To(BeFalse())
+	})
+
+	It("creates temporary files", func() {
+		name, err := fs.TempFile("spiff-")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(Exists(fs, name, false)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Overlay file system", func() {
+	var base, upper *MemoryFileSystem
+	var fs FileSystem
+
+	BeforeEach(func() {
+		base = NewMemoryFileSystem()
+		upper = NewMemoryFileSystem()
+		base.WriteFile("/dir/base", []byte("base"), 0644)
+		base.WriteFile("/dir/both", []byte("base"), 0644)
+		upper.WriteFile("/dir/both", []byte("upper"), 0644)
+		fs = NewOverlayFileSystem(upper, base)
+	})
+
+	It("prefers the upper layer", func() {
+		Expect(fs.ReadFile("/dir/both")).To(Equal([]byte("upper")))
+		Expect(fs.ReadFile("/dir/base")).To(Equal([]byte("base")))
+	})
+
+	It("writes to the upper layer", func() {
+		Expect(fs.WriteFile("/dir/base", []byte("new"), 0644)).To(Succeed())
+		Expect(fs.ReadFile("/dir/base")).To(Equal([]byte("new")))
+		Expect(base.ReadFile("/dir/base")).To(Equal([]byte("base")))
+	})
+
+	It("merges directories", func() {
+		upper.WriteFile("/dir/upper", nil, 0644)
+		infos, err := fs.ReadDir("/dir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(infos)).To(Equal([]string{"base", "both", "upper"}))
+	})
+})
+
+var _ = Describe("Tracking file system", func() {
+	It("records accessed files", func() {
+		mem := NewMemoryFileSystem()
+		Expect(mem.WriteFile("/a/file", []byte("data"), 0600)).To(Succeed())
+		fs := NewTrackingFileSystem(mem)
+
+		Expect(fs.ReadFile("/a/file")).To(Equal([]byte("data")))
+		fs.Stat("/a/missing")
+		fs.ReadDir("/a")
+		Expect(fs.WriteFile("/a/out", []byte("data"), 0600)).To(Succeed())
+		Expect(fs.Files()).To(Equal([]string{"/a", "/a/file", "/a/missing"}))
+	})
+})
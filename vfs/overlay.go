@@ -0,0 +1,70 @@
+package vfs
+
+import (
+	"os"
+	"sort"
+)
+
+// OverlayFileSystem layers a writable file system on top of a
+// read-only base file system. Files are read from the upper layer,
+// if present, otherwise from the base. All modifications are
+// done in the upper layer, only.
+type OverlayFileSystem struct {
+	upper FileSystem
+	base  FileSystem
+}
+
+var _ FileSystem = &OverlayFileSystem{}
+
+func NewOverlayFileSystem(upper, base FileSystem) *OverlayFileSystem {
+	return &OverlayFileSystem{upper: upper, base: base}
+}
+
+func (fs *OverlayFileSystem) ReadFile(name string) ([]byte, error) {
+	data, err := fs.upper.ReadFile(name)
+	if err != nil && os.IsNotExist(err) {
+		return fs.base.ReadFile(name)
+	}
+	return data, err
+}
+
+func (fs *OverlayFileSystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return fs.upper.WriteFile(name, data, perm)
+}
+
+func (fs *OverlayFileSystem) Stat(name string) (os.FileInfo, error) {
+	info, err := fs.upper.Stat(name)
+	if err != nil && os.IsNotExist(err) {
+		return fs.base.Stat(name)
+	}
+	return info, err
+}
+
+func (fs *OverlayFileSystem) ReadDir(name string) ([]os.FileInfo, error) {
+	upper, uerr := fs.upper.ReadDir(name)
+	base, berr := fs.base.ReadDir(name)
+	if uerr != nil && berr != nil {
+		return nil, uerr
+	}
+	found := map[string]bool{}
+	result := []os.FileInfo{}
+	for _, i := range upper {
+		found[i.Name()] = true
+		result = append(result, i)
+	}
+	for _, i := range base {
+		if !found[i.Name()] {
+			result = append(result, i)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (fs *OverlayFileSystem) Remove(name string) error {
+	return fs.upper.Remove(name)
+}
+
+func (fs *OverlayFileSystem) TempFile(prefix string) (string, error) {
+	return fs.upper.TempFile(prefix)
+}
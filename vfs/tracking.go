@@ -0,0 +1,54 @@
+package vfs
+
+import (
+	"os"
+	"sort"
+	"sync"
+)
+
+// TrackingFileSystem records the names of all files and directories
+// read through it. It is used to watch the inputs of a merge.
+type TrackingFileSystem struct {
+	FileSystem
+	lock  sync.Mutex
+	files map[string]bool
+}
+
+var _ FileSystem = &TrackingFileSystem{}
+
+func NewTrackingFileSystem(fs FileSystem) *TrackingFileSystem {
+	return &TrackingFileSystem{FileSystem: fs, files: map[string]bool{}}
+}
+
+func (fs *TrackingFileSystem) track(name string) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.files[name] = true
+}
+
+func (fs *TrackingFileSystem) ReadFile(name string) ([]byte, error) {
+	fs.track(name)
+	return fs.FileSystem.ReadFile(name)
+}
+
+func (fs *TrackingFileSystem) Stat(name string) (os.FileInfo, error) {
+	fs.track(name)
+	return fs.FileSystem.Stat(name)
+}
+
+func (fs *TrackingFileSystem) ReadDir(name string) ([]os.FileInfo, error) {
+	fs.track(name)
+	return fs.FileSystem.ReadDir(name)
+}
+
+// Files returns the sorted names of the accessed files and directories.
+func (fs *TrackingFileSystem) Files() []string {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	result := []string{}
+	for name := range fs.files {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
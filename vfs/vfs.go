@@ -0,0 +1,66 @@
+// Package vfs provides the virtual file system abstraction used by
+// the dynaml processing to access files. Besides the operating system
+// it offers an in-memory file system and an overlay of file systems
+// to run merges hermetically.
+package vfs
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// FileSystem is the interface for all file operations executed
+// during the processing of a document.
+type FileSystem interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	Stat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.FileInfo, error)
+	Remove(name string) error
+	// TempFile creates a new empty file in the default directory
+	// for temporary files and returns its name.
+	TempFile(prefix string) (string, error)
+}
+
+type osFileSystem struct{}
+
+// OSFileSystem is the file system of the operating system.
+var OSFileSystem FileSystem = osFileSystem{}
+
+func (osFileSystem) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(name)
+}
+
+func (osFileSystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return ioutil.WriteFile(name, data, perm)
+}
+
+func (osFileSystem) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFileSystem) ReadDir(name string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(name)
+}
+
+func (osFileSystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFileSystem) TempFile(prefix string) (string, error) {
+	file, err := ioutil.TempFile("", prefix)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return file.Name(), nil
+}
+
+// Exists checks whether a file or directory exists in a file system.
+func Exists(fs FileSystem, name string, directory bool) bool {
+	s, err := fs.Stat(name)
+	if err != nil {
+		return false
+	}
+	return s.IsDir() == directory
+}
@@ -0,0 +1,416 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func ToTOML(root Node) ([]byte, error) {
+	if root == nil {
+		return ValueToTOML(nil)
+	}
+	return ValueToTOML(root.Value())
+}
+
+// ValueToTOML marshals a map to a TOML document.
+// Null values are omitted.
+func ValueToTOML(root interface{}) ([]byte, error) {
+	n, err := normalizeValue(root)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := n.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("toml document requires a map")
+	}
+	buf := &bytes.Buffer{}
+	writeTOMLTable(buf, nil, m)
+	return buf.Bytes(), nil
+}
+
+var bareKey = regexp.MustCompile("^[A-Za-z0-9_-]+$")
+
+func tomlKey(key string) string {
+	if bareKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+func tomlPath(path []string) string {
+	keys := []string{}
+	for _, k := range path {
+		keys = append(keys, tomlKey(k))
+	}
+	return strings.Join(keys, ".")
+}
+
+func isTableArray(value interface{}) bool {
+	l, ok := value.([]interface{})
+	if !ok || len(l) == 0 {
+		return false
+	}
+	for _, e := range l {
+		if _, ok := e.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func writeTOMLTable(buf *bytes.Buffer, path []string, m map[string]interface{}) {
+	keys := sortedKeys(m)
+	for _, k := range keys {
+		v := m[k]
+		if _, ok := v.(map[string]interface{}); ok || v == nil || isTableArray(v) {
+			continue
+		}
+		fmt.Fprintf(buf, "%s = %s\n", tomlKey(k), tomlValue(v))
+	}
+	for _, k := range keys {
+		sub := append(append([]string{}, path...), k)
+		switch v := m[k].(type) {
+		case map[string]interface{}:
+			fmt.Fprintf(buf, "\n[%s]\n", tomlPath(sub))
+			writeTOMLTable(buf, sub, v)
+		case []interface{}:
+			if isTableArray(v) {
+				for _, e := range v {
+					fmt.Fprintf(buf, "\n[[%s]]\n", tomlPath(sub))
+					writeTOMLTable(buf, sub, e.(map[string]interface{}))
+				}
+			}
+		}
+	}
+}
+
+func tomlValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return tomlString(v)
+	case []byte:
+		return tomlString(string(v))
+	case float64:
+		switch {
+		case math.IsInf(v, 1):
+			return "inf"
+		case math.IsInf(v, -1):
+			return "-inf"
+		case math.IsNaN(v):
+			return "nan"
+		}
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eEn") {
+			s += ".0"
+		}
+		return s
+	case []interface{}:
+		elems := []string{}
+		for _, e := range v {
+			if e != nil {
+				elems = append(elems, tomlValue(e))
+			}
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case map[string]interface{}:
+		elems := []string{}
+		for _, k := range sortedKeys(v) {
+			if v[k] != nil {
+				elems = append(elems, tomlKey(k)+" = "+tomlValue(v[k]))
+			}
+		}
+		return "{" + strings.Join(elems, ", ") + "}"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func tomlString(s string) string {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('"')
+	for _, c := range s {
+		switch c {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(buf, `\u%04X`, c)
+			} else {
+				buf.WriteRune(c)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func ToEnv(root Node) ([]byte, error) {
+	if root == nil {
+		return ValueToEnv(nil)
+	}
+	return ValueToEnv(root.Value())
+}
+
+// ValueToEnv marshals a map to shell export statements. Nested keys
+// are joined by _ and converted to upper case, characters not allowed
+// in variable names are replaced by _. Keys mapped to the same variable
+// name are reported as error.
+func ValueToEnv(root interface{}) ([]byte, error) {
+	flat, err := flattenMap(root, "env", func(prefix, key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}, func(prefix string, index int) string {
+		return fmt.Sprintf("%s.%d", prefix, index)
+	})
+	if err != nil {
+		return nil, err
+	}
+	vars := map[string]string{}
+	keys := map[string]string{}
+	for _, k := range sortedKeys(flat) {
+		name := envName(k)
+		if other, ok := keys[name]; ok {
+			return nil, fmt.Errorf("keys %q and %q map to the same variable name %s", other, k, name)
+		}
+		keys[name] = k
+		vars[name] = flat[k]
+	}
+	buf := &bytes.Buffer{}
+	for _, k := range sortedKeys(vars) {
+		fmt.Fprintf(buf, "export %s='%s'\n", k, strings.Replace(vars[k], "'", `'\''`, -1))
+	}
+	return buf.Bytes(), nil
+}
+
+var invalidEnvChars = regexp.MustCompile("[^A-Z0-9_]")
+
+func envName(key string) string {
+	name := invalidEnvChars.ReplaceAllString(strings.ToUpper(key), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func ToProperties(root Node) ([]byte, error) {
+	if root == nil {
+		return ValueToProperties(nil)
+	}
+	return ValueToProperties(root.Value())
+}
+
+// ValueToProperties marshals a map to a Java properties file. Nested
+// keys are joined by dots, list entries are indexed by [<index>].
+func ValueToProperties(root interface{}) ([]byte, error) {
+	flat, err := flattenMap(root, "properties", func(prefix, key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}, func(prefix string, index int) string {
+		return fmt.Sprintf("%s[%d]", prefix, index)
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	for _, k := range sortedKeys(flat) {
+		fmt.Fprintf(buf, "%s=%s\n", propertiesEscape(k, true), propertiesEscape(flat[k], false))
+	}
+	return buf.Bytes(), nil
+}
+
+func propertiesEscape(s string, key bool) string {
+	buf := &bytes.Buffer{}
+	for i, c := range s {
+		switch c {
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case ' ':
+			if key || i == 0 {
+				buf.WriteString(`\ `)
+			} else {
+				buf.WriteRune(c)
+			}
+		case '=', ':', '#', '!':
+			if key || i == 0 {
+				buf.WriteByte('\\')
+			}
+			buf.WriteRune(c)
+		default:
+			buf.WriteRune(c)
+		}
+	}
+	return buf.String()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func ToHCL(root Node) ([]byte, error) {
+	if root == nil {
+		return ValueToHCL(nil)
+	}
+	return ValueToHCL(root.Value())
+}
+
+// ValueToHCL marshals a map to HCL attribute definitions
+// as used for Terraform variable files (tfvars).
+func ValueToHCL(root interface{}) ([]byte, error) {
+	n, err := normalizeValue(root)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := n.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("hcl document requires a map")
+	}
+	buf := &bytes.Buffer{}
+	for _, k := range sortedKeys(m) {
+		if !hclIdentifier.MatchString(k) {
+			return nil, fmt.Errorf("invalid hcl attribute name %q", k)
+		}
+		fmt.Fprintf(buf, "%s = ", k)
+		writeHCLValue(buf, m[k], "")
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+var hclIdentifier = regexp.MustCompile("^[A-Za-z_][A-Za-z0-9_-]*$")
+
+func writeHCLValue(buf *bytes.Buffer, value interface{}, indent string) {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case string:
+		buf.WriteString(hclString(v))
+	case []byte:
+		buf.WriteString(hclString(string(v)))
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return
+		}
+		buf.WriteString("[\n")
+		for _, e := range v {
+			buf.WriteString(indent + "  ")
+			writeHCLValue(buf, e, indent+"  ")
+			buf.WriteString(",\n")
+		}
+		buf.WriteString(indent + "]")
+	case map[string]interface{}:
+		if len(v) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		buf.WriteString("{\n")
+		for _, k := range sortedKeys(v) {
+			key := k
+			if !hclIdentifier.MatchString(k) {
+				key = hclString(k)
+			}
+			fmt.Fprintf(buf, "%s  %s = ", indent, key)
+			writeHCLValue(buf, v[k], indent+"  ")
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "}")
+	default:
+		fmt.Fprintf(buf, "%v", v)
+	}
+}
+
+func hclString(s string) string {
+	data, _ := json.Marshal(s)
+	s = strings.Replace(string(data), "${", "$${", -1)
+	return strings.Replace(s, "%{", "%%{", -1)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// flattenMap maps a map to a flat map of string values using
+// the given functions to compose the keys of nested values.
+func flattenMap(root interface{}, format string, key func(string, string) string, index func(string, int) string) (map[string]string, error) {
+	n, err := normalizeValue(root)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := n.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s document requires a map", format)
+	}
+	result := map[string]string{}
+	duplicate := ""
+	var flatten func(prefix string, value interface{})
+	flatten = func(prefix string, value interface{}) {
+		if _, ok := result[prefix]; ok {
+			duplicate = prefix
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for k, e := range v {
+				flatten(key(prefix, k), e)
+			}
+		case []interface{}:
+			for i, e := range v {
+				flatten(index(prefix, i), e)
+			}
+		case nil:
+			result[prefix] = ""
+		case []byte:
+			result[prefix] = string(v)
+		case float64:
+			result[prefix] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			result[prefix] = fmt.Sprintf("%v", v)
+		}
+	}
+	flatten("", m)
+	if duplicate != "" {
+		return nil, fmt.Errorf("duplicate %s key %q", format, duplicate)
+	}
+	return result, nil
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	switch v := m.(type) {
+	case map[string]interface{}:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	case map[string]string:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,116 @@
+package yaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("output formats", func() {
+	tree := parseYAML(`
+---
+name: demo
+port: 8080
+none: ~
+tags: [ a, "b c" ]
+server:
+  host: "it's"
+  "my key": 1.5
+users:
+- name: alice
+- name: bob
+`)
+
+	It("marshals toml", func() {
+		data, err := ToTOML(tree)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(`name = "demo"
+port = 8080
+tags = ["a", "b c"]
+
+[server]
+host = "it's"
+"my key" = 1.5
+
+[[users]]
+name = "alice"
+
+[[users]]
+name = "bob"
+`))
+	})
+
+	It("marshals export statements", func() {
+		data, err := ToEnv(tree)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(`export NAME='demo'
+export NONE=''
+export PORT='8080'
+export SERVER_HOST='it'\''s'
+export SERVER_MY_KEY='1.5'
+export TAGS_0='a'
+export TAGS_1='b c'
+export USERS_0_NAME='alice'
+export USERS_1_NAME='bob'
+`))
+	})
+
+	It("rejects keys mapped to the same variable name", func() {
+		_, err := ToEnv(parseYAML(`
+---
+a:
+  b: 1
+a_b: 2
+a-b: 3
+`))
+		Expect(err).To(MatchError(`keys "a-b" and "a.b" map to the same variable name A_B`))
+	})
+
+	It("rejects keys flattened to the same key", func() {
+		_, err := ToEnv(parseYAML(`
+---
+a:
+  b: 1
+a.b: 2
+`))
+		Expect(err).To(MatchError(`duplicate env key "a.b"`))
+	})
+
+	It("marshals properties", func() {
+		data, err := ToProperties(tree)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(`name=demo
+none=
+port=8080
+server.host=it's
+server.my\ key=1.5
+tags[0]=a
+tags[1]=b c
+users[0].name=alice
+users[1].name=bob
+`))
+	})
+
+	It("marshals hcl", func() {
+		data, err := ToHCL(parseYAML(`
+---
+region: eu
+cidrs: [ "10.0.0.0/16" ]
+tags:
+  owner: "${user}"
+`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(`cidrs = [
+  "10.0.0.0/16",
+]
+region = "eu"
+tags = {
+  owner = "$${user}"
+}
+`))
+	})
+
+	It("requires a map", func() {
+		_, err := ToTOML(parseYAML("[ a ]"))
+		Expect(err).To(MatchError("toml document requires a map"))
+	})
+})
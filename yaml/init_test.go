@@ -24,3 +24,23 @@ func parseYAML(source string) Node {
 func node(val interface{}) Node {
 	return NewNode(val, "test")
 }
+
+// withoutPositions strips the source positions from a parsed node
+// to compare it with nodes created by node().
+func withoutPositions(n Node) Node {
+	switch v := n.Value().(type) {
+	case map[string]Node:
+		m := map[string]Node{}
+		for k, e := range v {
+			m[k] = withoutPositions(e)
+		}
+		return NewNode(m, n.SourceName())
+	case []Node:
+		l := []Node{}
+		for _, e := range v {
+			l = append(l, withoutPositions(e))
+		}
+		return NewNode(l, n.SourceName())
+	}
+	return NewNode(n.Value(), n.SourceName())
+}
@@ -20,6 +20,9 @@ type Node interface {
 
 	Value() interface{}
 	SourceName() string
+	SourceLine() int
+	SourceColumn() int
+	SourceLocation() string
 	RedirectPath() []string
 	Flags() NodeFlags
 	Temporary() bool
@@ -45,6 +48,8 @@ type AnnotatedNode struct {
 	value      interface{}
 	resolver   RefResolver
 	sourceName string
+	line       int
+	column     int
 	Annotation
 }
 
@@ -152,14 +157,30 @@ type Annotation struct {
 }
 
 func copyNode(node Node) AnnotatedNode {
-	return AnnotatedNode{node.Value(), node.Resolver(), node.SourceName(), node.GetAnnotation()}
+	return AnnotatedNode{node.Value(), node.Resolver(), node.SourceName(), node.SourceLine(), node.SourceColumn(), node.GetAnnotation()}
 }
 func copyNodeAnnotated(node Node, anno Annotation) AnnotatedNode {
-	return AnnotatedNode{node.Value(), node.Resolver(), node.SourceName(), anno}
+	return AnnotatedNode{node.Value(), node.Resolver(), node.SourceName(), node.SourceLine(), node.SourceColumn(), anno}
 }
 
 func NewNode(value interface{}, sourcePath string) Node {
-	return AnnotatedNode{MassageType(value), nil, sourcePath, EmptyAnnotation()}
+	return AnnotatedNode{MassageType(value), nil, sourcePath, 0, 0, EmptyAnnotation()}
+}
+
+// NewPositionedNode creates a node remembering the line and column
+// of its value in the given source. A line of 0 means unknown.
+func NewPositionedNode(value interface{}, sourcePath string, line, column int) Node {
+	return AnnotatedNode{MassageType(value), nil, sourcePath, line, column, EmptyAnnotation()}
+}
+
+// PositionedNode creates a new node for the given value and source
+// taking over the source position of the given origin node, if it
+// stems from the same source.
+func PositionedNode(value interface{}, sourcePath string, origin Node) Node {
+	if origin == nil || origin.SourceName() != sourcePath {
+		return NewNode(value, sourcePath)
+	}
+	return NewPositionedNode(value, sourcePath, origin.SourceLine(), origin.SourceColumn())
 }
 
 func ResolverNode(node Node, resolver RefResolver) Node {
@@ -235,6 +256,8 @@ func MassageType(value interface{}) interface{} {
 	switch value.(type) {
 	case int, int8, int16, int32:
 		value = reflect.ValueOf(value).Int()
+	case float32:
+		value = reflect.ValueOf(value).Float()
 	}
 	return value
 }
@@ -360,6 +383,20 @@ func (n AnnotatedNode) SourceName() string {
 	return n.sourceName
 }
 
+func (n AnnotatedNode) SourceLine() int {
+	return n.line
+}
+
+func (n AnnotatedNode) SourceColumn() int {
+	return n.column
+}
+
+// SourceLocation returns the source name of the node, followed
+// by the line and column (file.yml:123:7), if it is known.
+func (n AnnotatedNode) SourceLocation() string {
+	return SourceLocation(n.sourceName, n.line, n.column)
+}
+
 func (n AnnotatedNode) Resolver() RefResolver {
 	return n.resolver
 }
@@ -437,6 +474,16 @@ func (n AnnotatedNode) EquivalentToNode(o Node) bool {
 	return b
 }
 
+func SourceLocation(source string, line, column int) string {
+	if line <= 0 {
+		return source
+	}
+	if column <= 0 {
+		return fmt.Sprintf("%s:%d", source, line)
+	}
+	return fmt.Sprintf("%s:%d:%d", source, line, column)
+}
+
 func EmbeddedDynaml(root Node) *string {
 	rootString, ok := root.Value().(string)
 	if !ok {
@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"github.com/cloudfoundry-incubator/candiedyaml"
+	"github.com/mandelsoft/spiff/yaml/decoder"
 	"reflect"
 	"time"
 )
@@ -35,7 +35,8 @@ func ParseMulti(sourceName string, source []byte) ([]Node, error) {
 		source = []byte("---\n")
 	}
 	r := bytes.NewBuffer(source)
-	d := candiedyaml.NewDecoder(r)
+	d := decoder.NewDecoder(r)
+	d.UsePositions()
 
 	for d.HasNext() {
 		var parsed interface{}
@@ -53,9 +54,15 @@ func ParseMulti(sourceName string, source []byte) ([]Node, error) {
 }
 
 func Sanitize(sourceName string, root interface{}) (Node, error) {
+	return sanitize(sourceName, root, 0, 0)
+}
+
+func sanitize(sourceName string, root interface{}, line, column int) (Node, error) {
 	switch rootVal := root.(type) {
+	case decoder.Positioned:
+		return sanitize(sourceName, rootVal.Value, rootVal.Line, rootVal.Column)
 	case time.Time:
-		return NewNode(rootVal.Format("2019-01-08T10:06:26Z"), sourceName), nil
+		return NewPositionedNode(rootVal.Format("2019-01-08T10:06:26Z"), sourceName, line, column), nil
 	case map[interface{}]interface{}:
 		sanitized := map[string]Node{}
 
@@ -73,7 +80,7 @@ func Sanitize(sourceName string, root interface{}) (Node, error) {
 			sanitized[str] = sub
 		}
 
-		return NewNode(sanitized, sourceName), nil
+		return NewPositionedNode(sanitized, sourceName, line, column), nil
 
 	case []interface{}:
 		sanitized := []Node{}
@@ -87,7 +94,7 @@ func Sanitize(sourceName string, root interface{}) (Node, error) {
 			sanitized = append(sanitized, sub)
 		}
 
-		return NewNode(sanitized, sourceName), nil
+		return NewPositionedNode(sanitized, sourceName, line, column), nil
 
 	case map[string]interface{}:
 		sanitized := map[string]Node{}
@@ -101,9 +108,9 @@ func Sanitize(sourceName string, root interface{}) (Node, error) {
 			sanitized[key] = sub
 		}
 
-		return NewNode(sanitized, sourceName), nil
+		return NewPositionedNode(sanitized, sourceName, line, column), nil
 	case string, []byte, int64, float64, bool, nil:
-		return NewNode(rootVal, sourceName), nil
+		return NewPositionedNode(rootVal, sourceName, line, column), nil
 	}
 
 	return nil, errors.New(fmt.Sprintf("unknown type (%s) during sanitization: %#v\n", reflect.TypeOf(root).String(), root))
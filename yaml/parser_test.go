@@ -11,7 +11,7 @@ var _ = Describe("YAML Parser", func() {
 		It("parses maps as strings mapping to Nodes", func() {
 			parsed, err := Parse("test", []byte(`foo: "fizz \"buzz\""`))
 			Expect(err).NotTo(HaveOccurred())
-			Expect(parsed).To(Equal(node(map[string]Node{"foo": node(`fizz "buzz"`)})))
+			Expect(parsed).To(Equal(NewPositionedNode(map[string]Node{"foo": NewPositionedNode(`fizz "buzz"`, "test", 1, 6)}, "test", 1, 1)))
 		})
 
 		It("parses maps with block string values", func() {
@@ -65,6 +65,43 @@ var _ = Describe("YAML Parser", func() {
 	//	})
 	//})
 
+	Context("source positions", func() {
+		It("remembers line and column of nodes", func() {
+			parsed := parseYAML(`
+---
+foo:
+  bar: 1
+  list:
+    - alice
+    - bob
+`)
+			foo, _ := Find(parsed, "foo")
+			Expect(foo.SourceLine()).To(Equal(4))
+			Expect(foo.SourceColumn()).To(Equal(3))
+
+			bar, _ := Find(parsed, "foo", "bar")
+			Expect(bar.SourceLocation()).To(Equal("test:4:8"))
+
+			bob, _ := Find(parsed, "foo", "list", "[1]")
+			Expect(bob.SourceLocation()).To(Equal("test:7:7"))
+		})
+
+		It("keeps positions for nodes of aliases", func() {
+			parsed := parseYAML(`
+---
+base: &base
+  alice: 25
+other: *base
+`)
+			alice, _ := Find(parsed, "other", "alice")
+			Expect(alice.SourceLocation()).To(Equal("test:4:10"))
+		})
+
+		It("omits unknown positions", func() {
+			Expect(node("foo").SourceLocation()).To(Equal("test"))
+		})
+	})
+
 	Context("parsing multi documents", func() {
 		It("returns all documents", func() {
 			sourceName := "test"
@@ -84,5 +121,5 @@ doc2:
 func parsesAs(source string, expr interface{}) {
 	parsed, err := Parse("test", []byte(source))
 	Expect(err).NotTo(HaveOccurred())
-	Expect(parsed).To(Equal(node(expr)))
+	Expect(withoutPositions(parsed)).To(Equal(node(expr)))
 }